@@ -8,15 +8,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"what_cook/domain"
 	"what_cook/food"
 	gormdep "what_cook/gorm"
+	"what_cook/history"
 	"what_cook/ingredient"
+	"what_cook/pantry"
+	"what_cook/plan"
+	"what_cook/tag"
+	"what_cook/user"
 )
 
 func main() {
 	listen := flag.String("listen", ":8080", "HTTP listen address")
+	admin := flag.String("admin", "", "name of a user who is granted the admin role")
+	importPath := flag.String("import", "", "csv or json file of food, ingredient, amount, unit rows "+
+		"to import as shared foods instead of serving HTTP")
+	flag.Parse()
 
 	logger := log.NewLogfmtLogger(os.Stderr)
 
@@ -28,18 +39,58 @@ func main() {
 		ingredientService    domain.IngredientService
 		foodRepository       domain.FoodRepository
 		foodService          domain.FoodService
+		pantryRepository     domain.PantryRepository
+		pantryService        domain.PantryService
+		tagRepository        domain.TagRepository
+		tagService           domain.TagService
+		userRepository       domain.UserRepository
+		userService          domain.UserService
+		historyService       domain.HistoryService
+		planService          domain.MealPlanService
 	)
 
 	db = gormdep.SqliteDbSession(gormdep.DSN_SQLITE)
+	userRepository = gormdep.NewUserRepository(db)
+	userService = user.NewService(userRepository)
+	authenticate := user.NewAuthenticator(userService)
+	if *admin != "" {
+		if err := grantAdmin(userRepository, userService, *admin); err != nil {
+			logger.Log("admin", *admin, "err", err)
+			os.Exit(1)
+		}
+	}
+
 	ingredientRepository = gormdep.NewIngredientRepository(db)
 	ingredientService = ingredient.NewService(ingredientRepository)
 
 	foodRepository = gormdep.NewFoodRepository(db)
 	foodService = food.NewFoodService(foodRepository)
+	if *importPath != "" {
+		if err := importFoods(foodService, *importPath, logger); err != nil {
+			logger.Log("import", *importPath, "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	pantryRepository = gormdep.NewPantryRepository(db)
+	pantryService = pantry.NewService(pantryRepository, foodService)
+
+	tagRepository = gormdep.NewTagRepository(db)
+	tagService = tag.NewService(tagRepository)
+
+	historyService = history.NewService(gormdep.NewHistoryRepository(db), foodService)
+
+	planService = plan.NewService(gormdep.NewMealPlanRepository(db), foodService)
 
 	mux := http.NewServeMux()
-	mux.Handle("/ingredient/", ingredient.MakeHandler(ingredientService, httpLogger))
-	mux.Handle("/food/", food.MakeHandler(foodService, httpLogger))
+	mux.Handle("/ingredient/", ingredient.MakeHandler(ingredientService, authenticate, httpLogger))
+	mux.Handle("/food/", food.MakeHandler(foodService, authenticate, httpLogger))
+	mux.Handle("/pantry/", pantry.MakeHandler(pantryService, authenticate, httpLogger))
+	mux.Handle("/tag/", tag.MakeHandler(tagService, authenticate, httpLogger))
+	mux.Handle("/user/", user.MakeHandler(userService, httpLogger))
+	mux.Handle("/me/", history.MakeHandler(historyService, authenticate, httpLogger))
+	mux.Handle("/plan/", plan.MakeHandler(planService, authenticate, httpLogger))
 	http.Handle("/", accessControl(mux))
 
 	errs := make(chan error, 2)
@@ -57,11 +108,51 @@ func main() {
 	logger.Log("terminated", <-errs)
 }
 
+// grantAdmin grants the admin role to the registered user
+func grantAdmin(ur domain.UserRepository, us domain.UserService, name string) error {
+	u, err := ur.FindByName(name)
+	if err != nil {
+		return err
+	}
+	return us.SetRole(u.ID, domain.AdminRole)
+}
+
+// importFoods imports the file as shared foods and logs the report, a .csv file is read as csv, json otherwise
+func importFoods(fs domain.FoodService, path string, logger log.Logger) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	mediaType := ""
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		mediaType = domain.CSVMediaType
+	}
+	rows, err := domain.ReadImportRows(file, mediaType)
+	if err != nil {
+		return err
+	}
+	report, err := fs.Import(domain.FoodImport{Rows: rows})
+	if err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		keyvals := []interface{}{"row", row.Row, "food", row.Food, "ingredient", row.Ingredient, "status", row.Status}
+		if row.Error != "" {
+			keyvals = append(keyvals, "err", row.Error)
+		}
+		logger.Log(keyvals...)
+	}
+	logger.Log("import", path, "created", report.Created, "updated", report.Updated,
+		"skipped", report.Skipped, "failed", report.Failed)
+	return nil
+}
+
 func accessControl(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
 			return
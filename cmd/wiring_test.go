@@ -14,13 +14,22 @@ import (
 	"what_cook/domain"
 	"what_cook/food"
 	"what_cook/gorm"
+	"what_cook/helper"
+	"what_cook/history"
 	"what_cook/ingredient"
+	"what_cook/pantry"
+	"what_cook/plan"
+	"what_cook/tag"
+	"what_cook/user"
 )
 
 type requestResponseTest struct {
 	method        string
 	url           string
 	body          string
+	token         string
+	accept        string
+	contentType   string
 	testResponses []testResponse
 }
 
@@ -57,6 +66,19 @@ func checkIngredient(t *testing.T, httpCode int, responseBody io.Reader) {
 	}
 }
 
+func checkPantry(t *testing.T, httpCode int, responseBody io.Reader) {
+	var body struct {
+		Pantry domain.Pantry `json:"Pantry"`
+	}
+	err := json.NewDecoder(responseBody).Decode(&body)
+	if err != nil {
+		t.Error(err)
+	}
+	if !body.Pantry.Equal(&testPantry) {
+		t.Error("pantry not equal test pantry")
+	}
+}
+
 func checkFood(t *testing.T, httpCode int, responseBody io.Reader) {
 	var body struct {
 		Food domain.Food `json:"Food"`
@@ -75,8 +97,19 @@ var (
 	testIngredient    domain.Ingredient
 	testFood          domain.Food
 	testFoods         []domain.Food
+	testPantry        domain.Pantry
+	testTag           domain.Tag
+	testUser          *domain.User
+	testToken         string
+	testReadKey       string
+	testViewer        *domain.User
+	testViewerToken   string
+	testPrivateFood   domain.Food
+	testViewerFood    domain.Food
 	foodService       domain.FoodService
+	pantryService     domain.PantryService
 	ingredientService domain.IngredientService
+	userService       domain.UserService
 	baseUrl           string
 )
 
@@ -92,14 +125,42 @@ func TestMain(m *testing.M) {
 		gorm.CreateRandomFood(db),
 		gorm.CreateRandomFood(db),
 	}
+	testPantry = gorm.CreateRandomPantry(db)
+	testTag = gorm.CreateRandomTag(db)
 	ingredientRepository := gorm.NewIngredientRepository(db)
 	ingredientService = ingredient.NewService(ingredientRepository)
 	foodRepository := gorm.NewFoodRepository(db)
 	foodService = food.NewFoodService(foodRepository)
+	pantryRepository := gorm.NewPantryRepository(db)
+	pantryService = pantry.NewService(pantryRepository, foodService)
+	tagService := tag.NewService(gorm.NewTagRepository(db))
+	userService = user.NewService(gorm.NewUserRepository(db))
+	authenticate := user.NewAuthenticator(userService)
+	testUser, _ = userService.Register("test_user"+helper.RandomName(), "password")
+	_, testToken, _ = userService.Login(testUser.Name, "password")
+	_, testReadKey, _ = userService.CreateAPIKey(testUser.ID, "read key", domain.ReadScope)
+	userService.SetRole(testUser.ID, domain.AdminRole)
+	testViewer, _ = userService.Register("test_user"+helper.RandomName(), "password")
+	_, testViewerToken, _ = userService.Login(testViewer.Name, "password")
+	testPrivateFood = gorm.RandomFood()
+	testPrivateFood.OwnerID = testUser.ID
+	testPrivateFood.Visibility = domain.PrivateFood
+	db.Create(&testPrivateFood)
+	testViewerFood = gorm.RandomFood()
+	testViewerFood.OwnerID = testViewer.ID
+	testViewerFood.Visibility = domain.PrivateFood
+	db.Create(&testViewerFood)
 	// server
 	mux := http.NewServeMux()
-	mux.Handle("/ingredient/", ingredient.MakeHandler(ingredientService, logger))
-	mux.Handle("/food/", food.MakeHandler(foodService, logger))
+	mux.Handle("/ingredient/", ingredient.MakeHandler(ingredientService, authenticate, logger))
+	mux.Handle("/food/", food.MakeHandler(foodService, authenticate, logger))
+	mux.Handle("/pantry/", pantry.MakeHandler(pantryService, authenticate, logger))
+	mux.Handle("/tag/", tag.MakeHandler(tagService, authenticate, logger))
+	mux.Handle("/user/", user.MakeHandler(userService, logger))
+	historyService := history.NewService(gorm.NewHistoryRepository(db), foodService)
+	mux.Handle("/me/", history.MakeHandler(historyService, authenticate, logger))
+	planService := plan.NewService(gorm.NewMealPlanRepository(db), foodService)
+	mux.Handle("/plan/", plan.MakeHandler(planService, authenticate, logger))
 	http.Handle("/", accessControl(mux))
 	srv := httptest.NewServer(mux)
 	defer srv.Close()
@@ -124,10 +185,25 @@ func TestWiring(t *testing.T) {
 			url:           "/ingredient/0",
 			testResponses: []testResponse{responseStatusIs(http.StatusNotFound)},
 		},
+		// check search
+		{
+			method: "GET",
+			url:    "/ingredient/search?q=" + testIngredient.Name[:len(testIngredient.Name)-1] + "&limit=5",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testIngredient.Name),
+			},
+		},
+		{
+			method:        "GET",
+			url:           "/ingredient/search?q=",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
 		// check create
 		{
 			method: "POST",
 			url:    "/ingredient/",
+			token:  testToken,
 			body:   "{\"ingredient\" : {\"name\" : \"chocolate\",\"calories\": 546}}",
 			testResponses: []testResponse{
 				responseStatusIs(http.StatusOK),
@@ -138,6 +214,7 @@ func TestWiring(t *testing.T) {
 		{
 			method: "PUT",
 			url:    "/ingredient/2",
+			token:  testToken,
 			body:   "{\"ingredient\" : {\"name\" : \"chocolate\",\"calories\" : 10}}",
 			testResponses: []testResponse{
 				responseStatusIs(http.StatusOK),
@@ -147,6 +224,49 @@ func TestWiring(t *testing.T) {
 		{
 			method: "DELETE",
 			url:    "/ingredient/2",
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+			},
+		},
+		// check substitutes
+		{
+			method: "POST",
+			url:    fmt.Sprintf("/ingredient/%d/substitutes", testIngredient.ID),
+			token:  testToken,
+			body:   fmt.Sprintf("{\"substitute\":{\"substituteId\":%d,\"ratio\":1.5}}", testFoods[0].IngredientWeights[0].IngredientID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"SubstituteID\""),
+			},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/ingredient/%d/substitutes", testIngredient.ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[0].IngredientWeights[0].Ingredient.Name),
+			},
+		},
+		{
+			method: "PUT",
+			url:    fmt.Sprintf("/ingredient/%d/substitutes/1", testIngredient.ID),
+			token:  testToken,
+			body:   "{\"substitute\":{\"note\":\"less salty\"}}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+			},
+		},
+		{
+			method:        "DELETE",
+			url:           "/ingredient/0/substitutes/1",
+			token:         testToken,
+			testResponses: []testResponse{responseStatusIs(http.StatusNotFound)},
+		},
+		{
+			method: "DELETE",
+			url:    fmt.Sprintf("/ingredient/%d/substitutes/1", testIngredient.ID),
+			token:  testToken,
 			testResponses: []testResponse{
 				responseStatusIs(http.StatusOK),
 			},
@@ -166,10 +286,123 @@ func TestWiring(t *testing.T) {
 				checkFood,
 			},
 		},
+		// check export
+		{
+			method: "GET",
+			url:    "/food/1",
+			accept: "text/markdown",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("# " + testFood.Name),
+			},
+		},
+		{
+			method: "GET",
+			url:    "/food/1",
+			accept: "text/csv, application/json;q=0.5",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("food,ingredient,amount,unit"),
+			},
+		},
+		{
+			method: "GET",
+			url:    "/ingredient/1",
+			accept: "text/plain",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("Aisle: " + domain.DefaultAisle),
+			},
+		},
+		{
+			method: "GET",
+			url:    "/ingredient/1",
+			accept: "application/json, text/plain;q=0.9",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				checkIngredient,
+			},
+		},
+		// check quantities
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d?unit=g", testFoods[0].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Unit\":\"g\""),
+			},
+		},
+		{
+			method:        "GET",
+			url:           fmt.Sprintf("/food/%d?unit=bucket", testFoods[0].ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		// check servings scaling
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d?servings=6", testFoods[0].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Servings\":6"),
+			},
+		},
+		{
+			method:        "GET",
+			url:           fmt.Sprintf("/food/%d?servings=-1", testFoods[0].ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		// check steps
+		{
+			method: "POST",
+			url:    fmt.Sprintf("/food/%d/steps", testFoods[0].ID),
+			token:  testToken,
+			body:   fmt.Sprintf("{\"step\":{\"text\":\"boil\",\"duration\":10,\"ingredients\":[{\"id\":%d}],\"equipment\":[\"pot\"]}}", testFoods[0].IngredientWeights[0].IngredientID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("StepID"),
+			},
+		},
+		{
+			method:        "POST",
+			url:           fmt.Sprintf("/food/%d/steps", testFoods[0].ID),
+			token:         testToken,
+			body:          "{\"step\":{\"text\":\"boil\",\"ingredients\":[{\"id\":0}]}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d", testFoods[0].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Equipment\":[\"pot\"]"),
+			},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d/steps", testFoods[0].ID),
+			token:         testToken,
+			body:          "{\"steps\":[0]}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		// check nutrition
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d/nutrition?calories=2500", testFoods[0].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Incomplete\":true"),
+			},
+		},
+		{
+			method:        "GET",
+			url:           fmt.Sprintf("/food/%d/nutrition?salt=-1", testFoods[0].ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
 		// check create
 		{
 			method: "POST",
 			url:    "/food/",
+			token:  testToken,
 			body:   "{\"food\":{\"name\":\"pasta\"}}",
 			testResponses: []testResponse{
 				responseStatusIs(http.StatusOK),
@@ -180,6 +413,7 @@ func TestWiring(t *testing.T) {
 		{
 			method: "PUT",
 			url:    "/food/1",
+			token:  testToken,
 			body:   "{\"food\":{\"name\":\"carbonara\"}}",
 			testResponses: []testResponse{
 				responseStatusIs(http.StatusOK),
@@ -189,6 +423,7 @@ func TestWiring(t *testing.T) {
 		{
 			method: "DELETE",
 			url:    "/food/1",
+			token:  testToken,
 			testResponses: []testResponse{
 				responseStatusIs(http.StatusOK),
 				func(t *testing.T, httpCode int, responseBody io.Reader) {
@@ -215,10 +450,636 @@ func TestWiring(t *testing.T) {
 				responseBodyContains(testFoods[0].Name),
 			},
 		},
+		// check list
+		{
+			method: "GET",
+			url:    "/food/?limit=2&sort=name&order=desc&name=test_food",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Total\":3"),
+			},
+		},
+		{
+			method:        "GET",
+			url:           "/food/?sort=unknown",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "GET",
+			url:    "/ingredient/?createdAfter=2020-01-01&minCalories=0",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Total\""),
+			},
+		},
+		// check unresolved ingredients
+		{
+			method: "GET",
+			url:    "/food/byIngredients/",
+			body:   "{\"ingredients\":[\"unknown ingredient\"]}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"UnresolvedIngredients\":[\"unknown ingredient\"]"),
+			},
+		},
+		// check unknown scorer
+		{
+			method:        "GET",
+			url:           "/food/byIngredients/?scorer=unknown",
+			body:          "{\"ingredients\":[\"pasta\"]}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		// check allergens and diets
+		{
+			method: "GET",
+			url:    "/food/byIngredients/",
+			body:   "{\"ingredients\":[\"pasta\"],\"excludeAllergens\":[\"egg\"],\"diet\":[\"vegetarian\"]}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+			},
+		},
+		{
+			method:        "GET",
+			url:           "/food/byIngredients/",
+			body:          "{\"ingredients\":[\"pasta\"],\"excludeAllergens\":[\"pollen\"]}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		// PANTRY
+		// check read
+		{
+			method:        "GET",
+			url:           "/pantry/0",
+			testResponses: []testResponse{responseStatusIs(http.StatusNotFound)},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/pantry/%d", testPantry.ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				checkPantry,
+			},
+		},
+		// check create
+		{
+			method: "POST",
+			url:    "/pantry/",
+			token:  testToken,
+			body:   fmt.Sprintf("{\"pantry\":{\"name\":\"home\",\"items\":[{\"ingredientId\":%d,\"weight\":0.5}]}}", testFoods[1].IngredientWeights[0].IngredientID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"PantryID\""),
+			},
+		},
+		// check recommendations from pantry
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/pantry/%d/foods", testPantry.ID+1),
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[1].Name),
+			},
+		},
+		// check update
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/pantry/%d", testPantry.ID),
+			token:         testViewerToken,
+			body:          "{\"pantry\":{\"name\":\"viewer cottage\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "DELETE",
+			url:           fmt.Sprintf("/pantry/%d", testPantry.ID),
+			token:         testViewerToken,
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method: "PUT",
+			url:    fmt.Sprintf("/pantry/%d", testPantry.ID),
+			token:  testToken,
+			body:   "{\"pantry\":{\"name\":\"cottage\"}}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+			},
+		},
+		// check delete
+		{
+			method: "DELETE",
+			url:    fmt.Sprintf("/pantry/%d", testPantry.ID),
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+			},
+		},
+		// check tags
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/tag/%d", testTag.ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testTag.Name),
+			},
+		},
+		{
+			method: "POST",
+			url:    "/tag/",
+			token:  testToken,
+			body:   "{\"tag\":{\"name\":\"breakfast\",\"kind\":\"meal\"}}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"TagID\""),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/tag/",
+			token:         testToken,
+			body:          "{\"tag\":{\"name\":\"sweet\",\"kind\":\"taste\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method:        "POST",
+			url:           "/tag/",
+			body:          "{\"tag\":{\"name\":\"lunch\",\"kind\":\"meal\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		{
+			method:        "POST",
+			url:           "/tag/",
+			token:         testReadKey,
+			body:          "{\"tag\":{\"name\":\"lunch\",\"kind\":\"meal\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/tag/%d", testTag.ID),
+			token:         testViewerToken,
+			body:          "{\"tag\":{\"name\":\"renamed\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "DELETE",
+			url:           fmt.Sprintf("/tag/%d", testTag.ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		{
+			method: "GET",
+			url:    "/tag/?kind=meal",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("breakfast"),
+			},
+		},
+		{
+			method: "PUT",
+			url:    fmt.Sprintf("/food/%d", testFoods[2].ID),
+			token:  testToken,
+			body:   fmt.Sprintf("{\"food\":{\"tags\":[{\"id\":%d}]}}", testTag.ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+			},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/?tags=%d", testTag.ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[2].Name),
+			},
+		},
+		{
+			method:        "GET",
+			url:           "/food/?excludeTags=italian",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		// USER
+		{
+			method: "POST",
+			url:    "/user/",
+			body:   "{\"name\":\"alice\",\"password\":\"secret password\"}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"UserID\""),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/user/",
+			body:          "{\"name\":\"alice\",\"password\":\"secret password\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusConflict)},
+		},
+		{
+			method:        "POST",
+			url:           "/user/",
+			body:          "{\"name\":\"   \",\"password\":\"secret password\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "POST",
+			url:    "/user/login",
+			body:   "{\"name\":\"alice\",\"password\":\"secret password\"}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Token\""),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/user/login",
+			body:          "{\"name\":\"alice\",\"password\":\"wrong password\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		{
+			method: "GET",
+			url:    "/user/me",
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testUser.Name),
+			},
+		},
+		{
+			method:        "GET",
+			url:           "/user/me",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		// check private foods
+		{
+			method:        "GET",
+			url:           fmt.Sprintf("/food/%d", testPrivateFood.ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusNotFound)},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d", testPrivateFood.ID),
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testPrivateFood.Name),
+			},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d", testViewerFood.ID),
+			token:         testViewerToken,
+			body:          "{\"food\":{\"name\":\"own private food\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d", testViewerFood.ID),
+			token:         testToken,
+			body:          "{\"food\":{\"name\":\"admin edited food\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method:        "GET",
+			url:           "/food/",
+			token:         "invalid",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		// check token scopes
+		{
+			method:        "POST",
+			url:           "/ingredient/",
+			body:          "{\"ingredient\":{\"name\":\"anonymous\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		{
+			method:        "DELETE",
+			url:           fmt.Sprintf("/food/%d", testPrivateFood.ID),
+			token:         testReadKey,
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "GET",
+			url:           fmt.Sprintf("/food/%d", testPrivateFood.ID),
+			token:         testReadKey,
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method:        "POST",
+			url:           "/user/keys",
+			token:         testReadKey,
+			body:          "{\"apiKey\":{\"name\":\"write key\",\"scope\":\"write\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method: "POST",
+			url:    "/user/keys",
+			token:  testToken,
+			body:   "{\"apiKey\":{\"name\":\"write key\",\"scope\":\"write\"}}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Key\""),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/user/keys",
+			token:         testToken,
+			body:          "{\"apiKey\":{\"name\":\"admin key\",\"scope\":\"admin\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "GET",
+			url:    "/user/keys",
+			token:  testReadKey,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("read key"),
+			},
+		},
+		// FAVOURITES AND HISTORY
+		{
+			method:        "GET",
+			url:           "/me/favourites",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		{
+			method:        "POST",
+			url:           "/me/favourites",
+			token:         testToken,
+			body:          fmt.Sprintf("{\"favourite\":{\"foodId\":%d}}", testFoods[0].ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method: "GET",
+			url:    "/me/favourites",
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[0].Name),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/me/history",
+			token:         testToken,
+			body:          fmt.Sprintf("{\"event\":{\"foodId\":%d,\"rating\":7}}", testFoods[0].ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "POST",
+			url:    "/me/history",
+			token:  testToken,
+			body:   fmt.Sprintf("{\"event\":{\"foodId\":%d,\"cookedAt\":\"2020-10-01T19:00:00Z\",\"rating\":4}}", testFoods[0].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Rating\":4"),
+			},
+		},
+		{
+			method: "GET",
+			url:    "/me/history",
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[0].Name),
+			},
+		},
+		{
+			method: "GET",
+			url:    "/food/byIngredients/",
+			token:  testToken,
+			body:   fmt.Sprintf("{\"ingredients\":[\"%s\"],\"boostFavourites\":true,\"recentDays\":3}", testFoods[0].IngredientWeights[0].Ingredient.Name),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Favourite\":true"),
+			},
+		},
+		// REVIEWS
+		{
+			method:        "POST",
+			url:           fmt.Sprintf("/food/%d/reviews", testFoods[1].ID),
+			token:         testToken,
+			body:          "{\"review\":{\"score\":6}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "POST",
+			url:    fmt.Sprintf("/food/%d/reviews", testFoods[1].ID),
+			token:  testToken,
+			body:   "{\"review\":{\"score\":4,\"text\":\"tasty\"}}",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"ReviewID\""),
+			},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d/reviews", testFoods[1].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("tasty"),
+			},
+		},
+		{
+			method: "GET",
+			url:    fmt.Sprintf("/food/%d", testFoods[1].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Rating\":{\"Average\":4,\"Count\":1,\"Distribution\":[0,0,0,1,0]}"),
+			},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d/reviews/1", testFoods[1].ID),
+			token:         testViewerToken,
+			body:          "{\"review\":{\"score\":1}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d/reviews/1", testFoods[1].ID),
+			token:         testToken,
+			body:          "{\"review\":{\"score\":5,\"text\":\"very tasty\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		// MEAL PLANS
+		{
+			method:        "POST",
+			url:           "/plan/",
+			body:          "{\"plan\":{\"name\":\"week\",\"startDate\":\"2020-10-05T00:00:00Z\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusUnauthorized)},
+		},
+		{
+			method:        "POST",
+			url:           "/plan/",
+			token:         testToken,
+			body:          fmt.Sprintf("{\"plan\":{\"name\":\"week\",\"startDate\":\"2020-10-05T00:00:00Z\",\"slots\":[{\"date\":\"2020-10-05T00:00:00Z\",\"meal\":\"supper\",\"foodId\":%d}]}}", testFoods[0].ID),
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method: "POST",
+			url:    "/plan/",
+			token:  testToken,
+			body:   fmt.Sprintf("{\"plan\":{\"name\":\"week\",\"startDate\":\"2020-10-05T00:00:00Z\",\"days\":2,\"slots\":[{\"date\":\"2020-10-05T00:00:00Z\",\"meal\":\"dinner\",\"foodId\":%d}]}}", testFoods[0].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"PlanID\":\"1\""),
+			},
+		},
+		{
+			method: "GET",
+			url:    "/plan/1",
+			token:  testToken,
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[0].Name),
+			},
+		},
+		{
+			method:        "GET",
+			url:           "/plan/1",
+			token:         testViewerToken,
+			testResponses: []testResponse{responseStatusIs(http.StatusNotFound)},
+		},
+		{
+			method: "POST",
+			url:    "/plan/1/autofill",
+			token:  testToken,
+			body:   fmt.Sprintf("{\"ingredients\":[\"%s\"],\"meals\":[\"lunch\"]}", testFoods[2].IngredientWeights[0].Ingredient.Name),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[2].Name),
+			},
+		},
+		{
+			method:        "DELETE",
+			url:           "/plan/1",
+			token:         testToken,
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method: "POST",
+			url:    "/food/shoppingList/",
+			body:   fmt.Sprintf("{\"foods\":[{\"foodId\":%d,\"servings\":2}]}", testFoods[2].ID),
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains(testFoods[2].IngredientWeights[0].Ingredient.Name),
+			},
+		},
+		{
+			method: "POST",
+			url:    "/food/shoppingList/",
+			body:   fmt.Sprintf("{\"foods\":[{\"foodId\":%d}]}", testFoods[2].ID),
+			accept: "text/markdown",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("- [ ] " + testFoods[2].IngredientWeights[0].Ingredient.Name),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/food/shoppingList/",
+			body:          "{\"foods\":[]}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method:      "POST",
+			url:         "/food/import/",
+			token:       testToken,
+			body:        "food,ingredient,amount,unit\nimported_food,imported_ingredient,100,g\n",
+			contentType: "text/csv",
+			testResponses: []testResponse{
+				responseStatusIs(http.StatusOK),
+				responseBodyContains("\"Status\":\"created\""),
+			},
+		},
+		{
+			method:        "POST",
+			url:           "/food/import/",
+			token:         testToken,
+			body:          "{\"food\":\"imported_food\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method:        "POST",
+			url:           "/food/import/",
+			token:         testViewerToken,
+			body:          "[]",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		// check roles
+		{
+			method:        "POST",
+			url:           "/ingredient/",
+			token:         testViewerToken,
+			body:          "{\"ingredient\":{\"name\":\"viewer ingredient\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d", testFoods[0].ID),
+			token:         testViewerToken,
+			body:          "{\"food\":{\"name\":\"viewer food\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "POST",
+			url:           "/food/",
+			token:         testViewerToken,
+			body:          "{\"food\":{\"name\":\"viewer food\",\"ingredientWeights\":[{\"ingredient\":{\"name\":\"viewer ingredient\"},\"weight\":0.1}]}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/food/%d", testViewerFood.ID),
+			token:         testViewerToken,
+			body:          "{\"food\":{\"ingredientWeights\":[{\"ingredient\":{\"name\":\"viewer ingredient\"},\"weight\":0.1}]}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method: "POST",
+			url:    "/food/",
+			token:  testViewerToken,
+			body: fmt.Sprintf("{\"food\":{\"name\":\"viewer food\",\"ingredientWeights\":[{\"ingredient\":{\"id\":%d,\"name\":\"%s\"},\"weight\":0.1}]}}",
+				testIngredient.ID, testIngredient.Name),
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/user/%d/role", testViewer.ID),
+			token:         testViewerToken,
+			body:          "{\"role\":\"admin\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusForbidden)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/user/%d/role", testViewer.ID),
+			token:         testToken,
+			body:          "{\"role\":\"owner\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusBadRequest)},
+		},
+		{
+			method:        "PUT",
+			url:           fmt.Sprintf("/user/%d/role", testViewer.ID),
+			token:         testToken,
+			body:          "{\"role\":\"editor\"}",
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
+		{
+			method:        "POST",
+			url:           "/ingredient/",
+			token:         testViewerToken,
+			body:          "{\"ingredient\":{\"name\":\"editor ingredient\"}}",
+			testResponses: []testResponse{responseStatusIs(http.StatusOK)},
+		},
 	}
 
 	for _, testcase := range requestResponseTestData {
 		req, _ := http.NewRequest(testcase.method, baseUrl+testcase.url, strings.NewReader(testcase.body))
+		if testcase.token != "" {
+			req.Header.Set("Authorization", "Bearer "+testcase.token)
+		}
+		if testcase.accept != "" {
+			req.Header.Set("Accept", testcase.accept)
+		}
+		if testcase.contentType != "" {
+			req.Header.Set("Content-Type", testcase.contentType)
+		}
 		resp, _ := http.DefaultClient.Do(req)
 
 		logger.Log("url", req.URL, "method", req.Method)
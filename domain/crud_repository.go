@@ -10,3 +10,38 @@ type CrudRepository interface {
 }
 
 var ModelNotFoundError = errors.New("not found")
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Page is an offset pagination with sorting of lists
+type Page struct {
+	Offset int
+	Limit  int
+	// Sort is a field to sort by, id if empty
+	Sort string
+	Desc bool
+}
+
+var InvalidPageError = errors.New("invalid page")
+
+// Validate checks the page and sets the default limit
+func (p *Page) Validate(sortFields ...string) error {
+	if p.Offset < 0 || p.Limit < 0 || p.Limit > MaxPageLimit {
+		return InvalidPageError
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Sort == "" {
+		p.Sort = "id"
+	}
+	for _, field := range sortFields {
+		if p.Sort == field {
+			return nil
+		}
+	}
+	return InvalidPageError
+}
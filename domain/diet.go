@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Allergens is a set of allergen flags, it is a json list of allergen names
+type Allergens uint
+
+const (
+	Gluten Allergens = 1 << iota
+	Shellfish
+	Egg
+	Fish
+	Peanuts
+	Nuts
+	Dairy
+	Soy
+	Celery
+	Mustard
+	Sesame
+	Sulphites
+	Lupin
+	Molluscs
+)
+
+var allergenNames = []string{"gluten", "shellfish", "egg", "fish", "peanuts", "nuts", "dairy",
+	"soy", "celery", "mustard", "sesame", "sulphites", "lupin", "molluscs"}
+
+var UnknownAllergenError = errors.New("unknown allergen")
+
+// Diets is a set of diet flags, it is a json list of diet names
+type Diets uint
+
+const (
+	Vegan Diets = 1 << iota
+	Vegetarian
+	Halal
+	Kosher
+)
+
+// AllDiets are flags of a food without ingredients
+const AllDiets = Vegan | Vegetarian | Halal | Kosher
+
+var dietNames = []string{"vegan", "vegetarian", "halal", "kosher"}
+
+var UnknownDietError = errors.New("unknown diet")
+
+func (a Allergens) Names() []string {
+	return flagNames(uint(a), allergenNames)
+}
+
+func (a Allergens) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Names())
+}
+
+func (a *Allergens) UnmarshalJSON(data []byte) error {
+	flags, err := parseFlags(data, allergenNames, UnknownAllergenError)
+	*a = Allergens(flags)
+	return err
+}
+
+func (d Diets) Names() []string {
+	return flagNames(uint(d), dietNames)
+}
+
+func (d Diets) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Names())
+}
+
+func (d *Diets) UnmarshalJSON(data []byte) error {
+	flags, err := parseFlags(data, dietNames, UnknownDietError)
+	*d = Diets(flags)
+	return err
+}
+
+func flagNames(flags uint, names []string) []string {
+	result := make([]string, 0)
+	for i, name := range names {
+		if flags&(1<<uint(i)) != 0 {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// parseFlags reads a json list of flag names, names are case insensitive
+func parseFlags(data []byte, names []string, unknownError error) (uint, error) {
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return 0, err
+	}
+	var flags uint
+	for _, item := range list {
+		found := false
+		for i, name := range names {
+			if strings.EqualFold(strings.TrimSpace(item), name) {
+				flags |= 1 << uint(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, unknownError
+		}
+	}
+	return flags, nil
+}
+
+// ComputeDiet sets allergens of all ingredients and diets compatible with every ingredient,
+// an ingredient without diet flags is not compatible with any diet, both are unknown (zero)
+// if the food has no ingredients or its ingredients are not loaded
+func (f *Food) ComputeDiet() {
+	f.Allergens = 0
+	f.Diets = 0
+	if len(f.IngredientWeights) == 0 {
+		return
+	}
+	for _, ingredientWeight := range f.IngredientWeights {
+		if ingredientWeight.IngredientID != 0 && ingredientWeight.Ingredient.ID == 0 {
+			return
+		}
+	}
+	f.Diets = AllDiets
+	for _, ingredientWeight := range f.IngredientWeights {
+		f.Allergens |= ingredientWeight.Ingredient.Allergens
+		f.Diets &= ingredientWeight.Ingredient.Diets
+	}
+}
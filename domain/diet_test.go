@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAllergens_JSON(t *testing.T) {
+	var allergens Allergens
+	if err := json.Unmarshal([]byte(`["Egg", "nuts"]`), &allergens); err != nil {
+		t.Fatal(err)
+	}
+	if allergens != Egg|Nuts {
+		t.Error("allergens are not parsed")
+	}
+	data, _ := json.Marshal(allergens)
+	if string(data) != `["egg","nuts"]` {
+		t.Error("allergens are not marshalled ", string(data))
+	}
+	if err := json.Unmarshal([]byte(`["pollen"]`), &allergens); err != UnknownAllergenError {
+		t.Error("err is not equal error ", UnknownAllergenError)
+	}
+	var diets Diets
+	if err := json.Unmarshal([]byte(`["paleo"]`), &diets); err != UnknownDietError {
+		t.Error("err is not equal error ", UnknownDietError)
+	}
+}
+
+func TestFood_ComputeDiet(t *testing.T) {
+	carbonara := Food{IngredientWeights: []IngredientWeight{
+		{Ingredient: Ingredient{Name: "spaghetti", Allergens: Gluten, Diets: Vegan | Vegetarian | Halal | Kosher}},
+		{Ingredient: Ingredient{Name: "egg", Allergens: Egg, Diets: Vegetarian | Halal | Kosher}},
+		{Ingredient: Ingredient{Name: "guanciale"}},
+	}}
+	carbonara.ComputeDiet()
+	if carbonara.Allergens != Gluten|Egg {
+		t.Error("wrong allergens ", carbonara.Allergens.Names())
+	}
+	if carbonara.Diets != 0 {
+		t.Error("wrong diets ", carbonara.Diets.Names())
+	}
+	carbonara.IngredientWeights = carbonara.IngredientWeights[:2]
+	carbonara.ComputeDiet()
+	if carbonara.Diets != Vegetarian|Halal|Kosher {
+		t.Error("wrong diets ", carbonara.Diets.Names())
+	}
+}
+
+func TestFood_ComputeDietUnknown(t *testing.T) {
+	// no ingredients
+	food := Food{Name: "water"}
+	food.ComputeDiet()
+	if food.Diets != 0 {
+		t.Error("food without ingredients has diets ", food.Diets)
+	}
+	// ingredients are not loaded
+	food.IngredientWeights = []IngredientWeight{{IngredientID: 1, Weight: 0.2}}
+	food.ComputeDiet()
+	if food.Diets != 0 || food.Allergens != 0 {
+		t.Error("food without loaded ingredients has diets ", food.Diets)
+	}
+}
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Media types of exported models
+const (
+	PlainTextMediaType = "text/plain"
+	MarkdownMediaType  = "text/markdown"
+	CSVMediaType       = "text/csv"
+)
+
+var ExportMediaTypes = []string{PlainTextMediaType, MarkdownMediaType, CSVMediaType}
+
+var UnknownMediaTypeError = errors.New("unknown media type")
+
+// Exportable is a model which can be rendered as text to be pasted into messengers and spreadsheets
+type Exportable interface {
+	Export(w io.Writer, mediaType string) error
+}
+
+// formatAmount formats the amount without trailing zeros, empty if the quantity is unknown
+func formatAmount(quantity *Quantity) (string, string) {
+	if quantity == nil || quantity.Amount == 0 {
+		return "", ""
+	}
+	return strconv.FormatFloat(quantity.Amount, 'f', -1, 64), quantity.Unit
+}
+
+// formatQuantity formats the quantity as "amount unit", empty if the quantity is unknown
+func formatQuantity(quantity *Quantity) string {
+	amount, unit := formatAmount(quantity)
+	return strings.TrimSpace(amount + " " + unit)
+}
+
+// csvCell prefixes the value with a quote if it starts with a formula character, so spreadsheets show it as text
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// quantity returns the quantity in the entered unit, in kg if the quantity is not converted, nil if it is unknown
+func (iw IngredientWeight) quantity() *Quantity {
+	if iw.Quantity != nil {
+		return iw.Quantity
+	}
+	if iw.Weight == 0 {
+		return nil
+	}
+	quantity := Quantity{Amount: iw.Weight, Unit: CanonicalUnit}.Round()
+	return &quantity
+}
+
+// Export renders the food with ingredients and steps, CSV has a (food, ingredient, amount, unit) row
+// per ingredient
+func (f *Food) Export(w io.Writer, mediaType string) error {
+	switch mediaType {
+	case PlainTextMediaType, MarkdownMediaType:
+		markdown := mediaType == MarkdownMediaType
+		var b strings.Builder
+		if markdown {
+			b.WriteString("# ")
+		}
+		fmt.Fprintf(&b, "%s\n\n", f.Name)
+		if f.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", f.Description)
+		}
+		fmt.Fprintf(&b, "Servings: %d\n", f.Servings)
+		if f.TotalMinutes != 0 {
+			fmt.Fprintf(&b, "Time: %d min\n", f.TotalMinutes)
+		}
+		if markdown {
+			b.WriteString("\n## ")
+		} else {
+			b.WriteString("\n")
+		}
+		b.WriteString("Ingredients\n")
+		if markdown {
+			b.WriteString("\n")
+		}
+		for _, ingredientWeight := range f.IngredientWeights {
+			b.WriteString("- " + ingredientWeight.Ingredient.Name)
+			if quantity := formatQuantity(ingredientWeight.quantity()); quantity != "" {
+				b.WriteString(", " + quantity)
+			}
+			b.WriteString("\n")
+		}
+		if len(f.Steps) != 0 {
+			if markdown {
+				b.WriteString("\n## ")
+			} else {
+				b.WriteString("\n")
+			}
+			b.WriteString("Steps\n")
+			if markdown {
+				b.WriteString("\n")
+			}
+			for i, step := range f.Steps {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, step.Text)
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	case CSVMediaType:
+		records := [][]string{{"food", "ingredient", "amount", "unit"}}
+		for _, ingredientWeight := range f.IngredientWeights {
+			amount, unit := formatAmount(ingredientWeight.quantity())
+			records = append(records, []string{csvCell(f.Name), csvCell(ingredientWeight.Ingredient.Name), amount, unit})
+		}
+		return csv.NewWriter(w).WriteAll(records)
+	}
+	return UnknownMediaTypeError
+}
+
+// Export renders items by aisles, markdown items are task list items to be checked in the store
+func (l ShoppingList) Export(w io.Writer, mediaType string) error {
+	switch mediaType {
+	case PlainTextMediaType, MarkdownMediaType:
+		markdown := mediaType == MarkdownMediaType
+		var b strings.Builder
+		for i, aisle := range l.Aisles {
+			if i != 0 {
+				b.WriteString("\n")
+			}
+			if markdown {
+				fmt.Fprintf(&b, "## %s\n\n", aisle.Aisle)
+			} else {
+				fmt.Fprintf(&b, "%s:\n", aisle.Aisle)
+			}
+			for _, item := range aisle.Items {
+				if markdown {
+					b.WriteString("- [ ] ")
+				} else {
+					b.WriteString("- ")
+				}
+				b.WriteString(item.Ingredient.Name)
+				if quantity := formatQuantity(item.Quantity); quantity != "" {
+					b.WriteString(", " + quantity)
+				}
+				b.WriteString("\n")
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	case CSVMediaType:
+		records := [][]string{{"aisle", "ingredient", "amount", "unit"}}
+		for _, aisle := range l.Aisles {
+			for _, item := range aisle.Items {
+				amount, unit := formatAmount(item.Quantity)
+				records = append(records, []string{csvCell(aisle.Aisle), csvCell(item.Ingredient.Name), amount, unit})
+			}
+		}
+		return csv.NewWriter(w).WriteAll(records)
+	}
+	return UnknownMediaTypeError
+}
+
+// Export renders the ingredient with its aisle and nutrients per 100 g
+func (i *Ingredient) Export(w io.Writer, mediaType string) error {
+	aisle := i.Aisle
+	if aisle == "" {
+		aisle = DefaultAisle
+	}
+	n := i.Nutrients
+	values := []float64{n.Calories, n.Protein, n.Fat, n.Carbohydrate, n.Fibre, n.Sugar, n.Salt}
+	formatted := make([]string, len(values))
+	for j, value := range values {
+		formatted[j] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	switch mediaType {
+	case PlainTextMediaType, MarkdownMediaType:
+		var b strings.Builder
+		prefix := ""
+		if mediaType == MarkdownMediaType {
+			b.WriteString("# ")
+			prefix = "- "
+		}
+		fmt.Fprintf(&b, "%s\n\n", i.Name)
+		fmt.Fprintf(&b, "%sAisle: %s\n", prefix, aisle)
+		fmt.Fprintf(&b, "%sPer 100 g: %s kcal, protein %s g, fat %s g, carbohydrate %s g, fibre %s g, sugar %s g, salt %s g\n",
+			prefix, formatted[0], formatted[1], formatted[2], formatted[3], formatted[4], formatted[5], formatted[6])
+		_, err := io.WriteString(w, b.String())
+		return err
+	case CSVMediaType:
+		records := [][]string{
+			{"ingredient", "aisle", "calories", "protein", "fat", "carbohydrate", "fibre", "sugar", "salt"},
+			append([]string{csvCell(i.Name), csvCell(aisle)}, formatted...),
+		}
+		return csv.NewWriter(w).WriteAll(records)
+	}
+	return UnknownMediaTypeError
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFood_Export(t *testing.T) {
+	food := Food{
+		Name:     "omelette",
+		Servings: 2,
+		IngredientWeights: []IngredientWeight{
+			{Ingredient: Ingredient{Name: "egg"}, Weight: 0.12, Quantity: &Quantity{Amount: 2, Unit: "piece"}},
+			{Ingredient: Ingredient{Name: "milk, whole"}, Weight: 0.1},
+			{Ingredient: Ingredient{Name: "salt"}},
+		},
+		Steps: []Step{{Text: "whisk"}, {Text: "fry"}},
+	}
+	var b strings.Builder
+	if err := food.Export(&b, MarkdownMediaType); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# omelette\n\nServings: 2\n\n## Ingredients\n\n- egg, 2 piece\n- milk, whole, 0.1 kg\n- salt\n\n" +
+		"## Steps\n\n1. whisk\n2. fry\n"
+	if b.String() != expected {
+		t.Errorf("wrong markdown:\n%s", b.String())
+	}
+	b.Reset()
+	if err := food.Export(&b, CSVMediaType); err != nil {
+		t.Fatal(err)
+	}
+	expected = "food,ingredient,amount,unit\nomelette,egg,2,piece\nomelette,\"milk, whole\",0.1,kg\nomelette,salt,,\n"
+	if b.String() != expected {
+		t.Errorf("wrong csv:\n%s", b.String())
+	}
+	// check formulas
+	b.Reset()
+	formula := Food{Name: "=1+2", IngredientWeights: []IngredientWeight{{Ingredient: Ingredient{Name: "@egg"}}}}
+	if err := formula.Export(&b, CSVMediaType); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "food,ingredient,amount,unit\n'=1+2,'@egg,,\n"; b.String() != expected {
+		t.Errorf("formula is not escaped:\n%s", b.String())
+	}
+	if err := food.Export(&b, "text/html"); err != UnknownMediaTypeError {
+		t.Error("err is not equal error ", UnknownMediaTypeError)
+	}
+}
+
+func TestShoppingList_Export(t *testing.T) {
+	list := ShoppingList{Aisles: []ShoppingAisle{
+		{Aisle: "dairy", Items: []ShoppingItem{{Ingredient: Ingredient{Name: "egg"}, Quantity: &Quantity{Amount: 4, Unit: "piece"}}}},
+		{Aisle: DefaultAisle, Items: []ShoppingItem{{Ingredient: Ingredient{Name: "salt"}}}},
+	}}
+	var b strings.Builder
+	if err := list.Export(&b, PlainTextMediaType); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "dairy:\n- egg, 4 piece\n\nother:\n- salt\n"; b.String() != expected {
+		t.Errorf("wrong text:\n%s", b.String())
+	}
+	// check formulas starting with a tab or a carriage return
+	b.Reset()
+	formula := ShoppingList{Aisles: []ShoppingAisle{
+		{Aisle: "\tdairy", Items: []ShoppingItem{{Ingredient: Ingredient{Name: "\regg"}}}},
+	}}
+	if err := formula.Export(&b, CSVMediaType); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "aisle,ingredient,amount,unit\n'\tdairy,\"'\regg\",,\n"; b.String() != expected {
+		t.Errorf("formula is not escaped:\n%q", b.String())
+	}
+}
+
+func TestIngredient_Export(t *testing.T) {
+	ingredient := Ingredient{Name: "-salt", Aisle: "+spices"}
+	var b strings.Builder
+	if err := ingredient.Export(&b, CSVMediaType); err != nil {
+		t.Fatal(err)
+	}
+	expected := "ingredient,aisle,calories,protein,fat,carbohydrate,fibre,sugar,salt\n'-salt,'+spices,0,0,0,0,0,0,0\n"
+	if b.String() != expected {
+		t.Errorf("wrong csv:\n%s", b.String())
+	}
+}
@@ -1,15 +1,94 @@
 package domain
 
 import (
+	"encoding/json"
 	"errors"
 	"gorm.io/gorm"
+	"math"
+	"sort"
+	"time"
 )
 
 type Food struct {
 	gorm.Model
 	Name              string
 	Description       string
+	Servings          uint `gorm:"default:1"`
+	OwnerID           uint `gorm:"index"` // 0 if the food is shared
+	Visibility        Visibility
+	PrepMinutes       uint `gorm:"default:0"`
+	CookMinutes       uint `gorm:"default:0"`
 	IngredientWeights []IngredientWeight
+	Steps             []Step
+	Tags              []Tag `gorm:"many2many:food_tags" validate:"-"`
+	// TotalMinutes is preparation and cooking time, zero if times are not set
+	TotalMinutes uint `gorm:"-"`
+	// Allergens and Diets are computed from loaded ingredients
+	Allergens Allergens `gorm:"-"`
+	Diets     Diets     `gorm:"-"`
+	// Nutrition is computed from loaded ingredient weights
+	Nutrition *FoodNutrition `gorm:"-"`
+	// Rating is aggregated from reviews by the repository
+	Rating Rating `gorm:"-"`
+}
+
+type Visibility string
+
+const (
+	PrivateFood Visibility = "private"
+	PublicFood  Visibility = "public"
+)
+
+var InvalidVisibilityError = errors.New("visibility must be private or public")
+
+// CheckVisibility sets the private visibility if it is empty and checks the visibility is known
+func (f *Food) CheckVisibility() error {
+	if f.Visibility == "" {
+		f.Visibility = PrivateFood
+	}
+	if f.Visibility != PrivateFood && f.Visibility != PublicFood {
+		return InvalidVisibilityError
+	}
+	return nil
+}
+
+// VisibleTo reports whether the user can see the food, shared and public foods are visible to everyone
+func (f *Food) VisibleTo(userID uint) bool {
+	return f.OwnerID == 0 || f.Visibility == PublicFood || f.OwnerID == userID
+}
+
+// HasNewIngredients reports whether saving the food creates ingredients which are not in the catalogue yet
+func (f *Food) HasNewIngredients() bool {
+	for _, ingredientWeight := range f.IngredientWeights {
+		if ingredientWeight.IngredientID == 0 && ingredientWeight.Ingredient.ID == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Food) AfterFind(tx *gorm.DB) error {
+	f.TotalMinutes = f.PrepMinutes + f.CookMinutes
+	f.ComputeDiet()
+	sort.SliceStable(f.Steps, func(i, j int) bool {
+		return f.Steps[i].Position < f.Steps[j].Position
+	})
+	nutrition := f.ComputeNutrition()
+	f.Nutrition = &nutrition
+	// quantities in unknown units are left empty
+	f.ConvertQuantities("")
+	return nil
+}
+
+// ConvertQuantities sets quantities of ingredient weights in the unit, in units as entered if empty
+func (f *Food) ConvertQuantities(unit string) error {
+	var err error
+	for i := range f.IngredientWeights {
+		if e := f.IngredientWeights[i].ConvertQuantity(unit); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 func (f *Food) Equal(food interface{}) bool {
@@ -23,40 +102,366 @@ func (f *Food) Equal(food interface{}) bool {
 	return f2.Name == f.Name
 }
 
+// Scale scales ingredient weights and nutrition to the servings,
+// quantities are rounded by their units to be displayed, weights are not rounded
+func (f *Food) Scale(servings uint) {
+	if servings == 0 {
+		return
+	}
+	currentServings := f.Servings
+	if currentServings == 0 {
+		currentServings = 1
+	}
+	k := float64(servings) / float64(currentServings)
+	for i := range f.IngredientWeights {
+		ingredientWeight := &f.IngredientWeights[i]
+		ingredientWeight.Weight *= k
+		unit := ingredientWeight.Unit
+		if ingredientWeight.Quantity != nil {
+			unit = ingredientWeight.Quantity.Unit
+		}
+		if unit == "" {
+			unit = CanonicalUnit
+		}
+		quantity, err := QuantityOf(ingredientWeight.Weight, unit, ingredientWeight.Ingredient)
+		if err != nil {
+			ingredientWeight.Quantity = nil
+			continue
+		}
+		quantity = quantity.Round()
+		ingredientWeight.Quantity = &quantity
+	}
+	f.Servings = servings
+	nutrition := f.ComputeNutrition()
+	f.Nutrition = &nutrition
+}
+
+// ComputeNutrition sums nutrients of ingredients by weights
+func (f *Food) ComputeNutrition() FoodNutrition {
+	nutrition := FoodNutrition{Servings: f.Servings}
+	if nutrition.Servings == 0 {
+		nutrition.Servings = 1
+	}
+	for _, ingredientWeight := range f.IngredientWeights {
+		// nutrients are per 100 g, weight is in kg
+		nutrients := ingredientWeight.Ingredient.Nutrients.Scale(ingredientWeight.Weight * 10)
+		nutrition.Total = nutrition.Total.Add(nutrients)
+	}
+	nutrition.PerServing = nutrition.Total.Scale(1 / float64(nutrition.Servings))
+	return nutrition
+}
+
+// Calories returns food energy, kcal
+func (f *Food) Calories() float64 {
+	return f.ComputeNutrition().Total.Calories
+}
+
+// IngredientQuantity is an ingredient available for cooking, as requested by a client
+type IngredientQuantity struct {
+	Name   string
+	Weight float64 //kg, 0 if the quantity is unknown
+	// Quantity replaces the weight, the ingredient is unresolved if it can't be converted
+	Quantity   *Quantity
+	BestBefore *time.Time // optional
+}
+
+// UnmarshalJSON accepts a plain ingredient name as well as a {"name", "weight", "bestBefore"} object
+func (iq *IngredientQuantity) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*iq = IngredientQuantity{Name: name}
+		return nil
+	}
+	type plainIngredientQuantity IngredientQuantity
+	return json.Unmarshal(data, (*plainIngredientQuantity)(iq))
+}
+
+// DefaultExpiringDays is how many days before the best-before date an ingredient is expiring soon
+const DefaultExpiringDays = 3
+
+type FoodsByIngredientsQuery struct {
+	Ingredients []IngredientQuantity
+	// Date is the reference date for best-before dates, now if zero
+	Date time.Time
+	// ExpiringDays is the expiring soon period, DefaultExpiringDays if zero
+	ExpiringDays uint
+	// Scorer ranks found foods, CoverageScorer if nil
+	Scorer Scorer `json:"-"`
+	// MaxTotalMinutes limits total time of found foods, foods without times are skipped, no limit if zero
+	MaxTotalMinutes uint
+	// ViewerID is the user who can see found foods
+	ViewerID uint `json:"-"`
+	TagFilter
+	// ExcludeAllergens skips foods with ingredients which have one of the allergens
+	ExcludeAllergens Allergens
+	// Diet skips foods with ingredients which are not compatible with every diet of the set
+	Diet Diets
+	// BoostFavourites ranks favourite foods of the viewer higher
+	BoostFavourites bool
+	// RecentDays ranks foods cooked by the viewer within the days lower, not lowered if zero
+	RecentDays uint
+}
+
+func (q FoodsByIngredientsQuery) IngredientNames() []string {
+	names := make([]string, len(q.Ingredients))
+	for i, ingredient := range q.Ingredients {
+		names[i] = ingredient.Name
+	}
+	return names
+}
+
+// Stock makes a stock from requested quantities of resolved ingredients,
+// resolved is a map of ingredients by normalized name, names of ingredients which are not found
+// or which quantities can't be converted are returned as unresolved
+func (q FoodsByIngredientsQuery) Stock(resolved map[string]Ingredient) (Stock, []string) {
+	stock := Stock{
+		Items:        make([]StockItem, 0, len(q.Ingredients)),
+		Date:         q.Date,
+		ExpiringDays: q.ExpiringDays,
+	}
+	if stock.Date.IsZero() {
+		stock.Date = time.Now()
+	}
+	if stock.ExpiringDays == 0 {
+		stock.ExpiringDays = DefaultExpiringDays
+	}
+	unresolved := make([]string, 0)
+	itemIndexes := make(map[uint]int)
+	for _, ingredientQuantity := range q.Ingredients {
+		ingredient, ok := resolved[NormalizeIngredientName(ingredientQuantity.Name)]
+		if !ok {
+			unresolved = append(unresolved, ingredientQuantity.Name)
+			continue
+		}
+		weight := ingredientQuantity.Weight
+		if ingredientQuantity.Quantity != nil {
+			var err error
+			// an unconvertible quantity is not an unknown one, which would cover any amount
+			if weight, err = ingredientQuantity.Quantity.Kilograms(ingredient); err != nil {
+				unresolved = append(unresolved, ingredientQuantity.Name)
+				continue
+			}
+		}
+		i, ok := itemIndexes[ingredient.ID]
+		if !ok {
+			i = len(stock.Items)
+			itemIndexes[ingredient.ID] = i
+			stock.Items = append(stock.Items, StockItem{Ingredient: ingredient})
+		}
+		stock.Items[i].Weight += weight
+		bestBefore := ingredientQuantity.BestBefore
+		if bestBefore != nil && (stock.Items[i].BestBefore == nil || bestBefore.Before(*stock.Items[i].BestBefore)) {
+			stock.Items[i].BestBefore = bestBefore
+		}
+	}
+	return stock, unresolved
+}
+
+// StockItem is a resolved available ingredient with its quantity
+type StockItem struct {
+	Ingredient Ingredient
+	Weight     float64    //kg, 0 if the quantity is unknown
+	BestBefore *time.Time // optional
+}
+
+// Stock is a set of available ingredients at the date
+type Stock struct {
+	Items []StockItem
+	// Substitutes are substitutions by available ingredients
+	Substitutes  []IngredientSubstitute
+	Date         time.Time
+	ExpiringDays uint
+}
+
+func (s Stock) find(ingredientID uint) (StockItem, bool) {
+	for _, stockItem := range s.Items {
+		if stockItem.Ingredient.ID == ingredientID {
+			return stockItem, true
+		}
+	}
+	return StockItem{}, false
+}
+
+// substitute searches an available substitute for the ingredient
+func (s Stock) substitute(ingredientID uint) (IngredientSubstitute, StockItem, bool) {
+	for _, substitute := range s.Substitutes {
+		if substitute.IngredientID != ingredientID {
+			continue
+		}
+		if stockItem, ok := s.find(substitute.SubstituteID); ok {
+			return substitute, stockItem, true
+		}
+	}
+	return IngredientSubstitute{}, StockItem{}, false
+}
+
+// urgency returns how soon the item spoils, from 0 (not expiring soon) to 1 (expires today or expired)
+func (s Stock) urgency(item StockItem) float64 {
+	if item.BestBefore == nil {
+		return 0
+	}
+	daysLeft := math.Floor(item.BestBefore.Sub(s.Date).Hours() / 24)
+	period := float64(s.ExpiringDays)
+	if daysLeft > period {
+		return 0
+	}
+	return (period + 1 - math.Max(daysLeft, 0)) / (period + 1)
+}
+
+// IngredientShortage is an available ingredient which is not enough for the food
+type IngredientShortage struct {
+	Ingredient Ingredient
+	Needed     float64 //kg
+	Available  float64 //kg
+	Shortage   float64 //kg
+}
+
+// ExpiringIngredient is an available ingredient which expires soon
+type ExpiringIngredient struct {
+	Ingredient Ingredient
+	BestBefore time.Time
+}
+
 type FoodRecommendation struct {
-	Food              Food
-	HasIngredients    []Ingredient
-	AbsentIngredients []Ingredient
+	Food                Food
+	HasIngredients      []Ingredient
+	AbsentIngredients   []Ingredient
+	Shortages           []IngredientShortage
+	ExpiringIngredients []ExpiringIngredient
+	// Substitutions are used to replace absent food ingredients by available ones
+	Substitutions []IngredientSubstitute
+	// Coverage is the average share of each food ingredient covered by the stock, from 0 to 1
+	Coverage float64
+	// Urgency is the sum of urgencies of consumed expiring ingredients
+	Urgency float64
+	// Favourite is set if the viewer marked the food
+	Favourite bool
+	// LastCooked is when the viewer cooked the food last time, nil if never
+	LastCooked *time.Time
+	// Preference is the favourite boost lowered by recent cooking, it is added to the score
+	Preference float64
+	// Score is the rank of the recommendation given by a scorer, boosted by urgency and preference
+	Score float64
 }
 
-func FoodToFoodRecommendation(f Food, ingredients []Ingredient) FoodRecommendation {
+func FoodToFoodRecommendation(f Food, stock Stock) FoodRecommendation {
 	foodRecommendation := FoodRecommendation{
-		Food:              f,
-		HasIngredients:    make([]Ingredient, 0),
-		AbsentIngredients: make([]Ingredient, 0),
+		Food:                f,
+		HasIngredients:      make([]Ingredient, 0),
+		AbsentIngredients:   make([]Ingredient, 0),
+		Shortages:           make([]IngredientShortage, 0),
+		ExpiringIngredients: make([]ExpiringIngredient, 0),
+		Substitutions:       make([]IngredientSubstitute, 0),
 	}
 	// search has or absent ingredients
+	covered := 0.0
 	for _, ingredientWeight := range f.IngredientWeights {
-		absent := true
-		for _, ingredient := range ingredients {
-			if ingredient.ID == ingredientWeight.IngredientID {
-				foodRecommendation.HasIngredients = append(foodRecommendation.HasIngredients, ingredient)
-				absent = false
-				break
+		needed := ingredientWeight.Weight
+		stockItem, ok := stock.find(ingredientWeight.IngredientID)
+		if !ok {
+			var substitute IngredientSubstitute
+			substitute, stockItem, ok = stock.substitute(ingredientWeight.IngredientID)
+			if ok {
+				foodRecommendation.Substitutions = append(foodRecommendation.Substitutions, substitute)
+				needed = substitute.SubstituteWeight(needed)
 			}
 		}
-		if absent {
+		if !ok {
 			foodRecommendation.AbsentIngredients = append(foodRecommendation.AbsentIngredients, ingredientWeight.Ingredient)
+			continue
+		}
+		foodRecommendation.HasIngredients = append(foodRecommendation.HasIngredients, stockItem.Ingredient)
+		covered += ingredientCoverage(needed, stockItem.Weight)
+		if stockItem.Weight > 0 && stockItem.Weight < needed {
+			foodRecommendation.Shortages = append(foodRecommendation.Shortages, IngredientShortage{
+				Ingredient: stockItem.Ingredient,
+				Needed:     needed,
+				Available:  stockItem.Weight,
+				Shortage:   needed - stockItem.Weight,
+			})
+		}
+		if urgency := stock.urgency(stockItem); urgency > 0 {
+			foodRecommendation.ExpiringIngredients = append(foodRecommendation.ExpiringIngredients, ExpiringIngredient{
+				Ingredient: stockItem.Ingredient,
+				BestBefore: *stockItem.BestBefore,
+			})
+			foodRecommendation.Urgency += urgency
 		}
 	}
+	if len(f.IngredientWeights) > 0 {
+		foodRecommendation.Coverage = covered / float64(len(f.IngredientWeights))
+	}
 	return foodRecommendation
 }
 
+// ingredientCoverage returns the covered share of the needed weight,
+// unknown quantities are considered as enough
+func ingredientCoverage(needed float64, available float64) float64 {
+	if needed <= 0 || available <= 0 || available >= needed {
+		return 1
+	}
+	return available / needed
+}
+
+type FoodsByIngredientsResult struct {
+	Foods []FoodRecommendation
+	// UnresolvedIngredients are requested names which are not found in ingredients and aliases
+	UnresolvedIngredients []string
+	// Facets are tag counts of found foods
+	Facets []TagFacet
+}
+
+// FoodListQuery filters foods, all filters are optional
+type FoodListQuery struct {
+	Page
+	NameContains string
+	CreatedAfter *time.Time
+	// Ingredient is a name or an alias of an ingredient which foods contain
+	Ingredient  string
+	MinCalories *float64
+	MaxCalories *float64
+	// ViewerID is the user who can see listed foods
+	ViewerID uint
+	TagFilter
+}
+
+var FoodSortFields = []string{"id", "name", "createdAt", "calories"}
+
+type FoodList struct {
+	Foods  []Food
+	Total  int64
+	Offset int
+	Limit  int
+	// Facets are tag counts of all filtered foods
+	Facets []TagFacet
+}
+
 var FoodNotFoundError = errors.New("food not found")
 
 type FoodRepository interface {
 	CrudRepository
-	FindByIngredients(ingredients []string) ([]FoodRecommendation, error)
+	FindByIngredients(query FoodsByIngredientsQuery) (FoodsByIngredientsResult, error)
+	List(query FoodListQuery) (FoodList, error)
+	Steps(foodID uint) ([]Step, error)
+	GetStep(id uint) (*Step, error)
+	// SaveStep inserts the step at its position, the step is appended if the position is not set
+	SaveStep(step *Step) error
+	UpdateStep(id uint, step *Step) error
+	DeleteStep(id uint) error
+	// ReorderSteps sets positions of the food steps by the order of ids
+	ReorderSteps(foodID uint, stepIDs []uint) error
+	// Reviews returns reviews of the food with authors, the latest first
+	Reviews(foodID uint) ([]Review, error)
+	GetReview(id uint) (*Review, error)
+	// SaveReview replaces the score and the text of the review if the author already reviewed the food
+	SaveReview(review *Review) error
+	// UpdateReview changes the score and the text of the review
+	UpdateReview(id uint, review *Review) error
+	DeleteReview(id uint) error
+	// ResolveIngredients finds ingredients by names and aliases, returns a map of ingredients by normalized name
+	ResolveIngredients(names []string) (map[string]Ingredient, error)
+	// Import runs the import in a transaction, failed rows are reported and not imported
+	Import(foodImport FoodImport) (ImportReport, error)
 }
 
 type FoodService interface {
@@ -64,5 +469,19 @@ type FoodService interface {
 	Update(id uint, food *Food) error
 	Delete(id uint) error
 	Get(id uint) (*Food, error)
-	FindByIngredients(ingredients []string) ([]FoodRecommendation, error)
+	FindByIngredients(query FoodsByIngredientsQuery) (FoodsByIngredientsResult, error)
+	List(query FoodListQuery) (FoodList, error)
+	Nutrition(id uint, referenceIntake Nutrients) (NutritionReport, error)
+	Steps(foodID uint) ([]Step, error)
+	SaveStep(foodID uint, step *Step) error
+	UpdateStep(foodID uint, id uint, step *Step) error
+	DeleteStep(foodID uint, id uint) error
+	ReorderSteps(foodID uint, stepIDs []uint) ([]Step, error)
+	Reviews(foodID uint) ([]Review, error)
+	GetReview(foodID uint, id uint) (*Review, error)
+	SaveReview(foodID uint, review *Review) error
+	UpdateReview(foodID uint, id uint, review *Review) error
+	DeleteReview(foodID uint, id uint) error
+	ShoppingList(query ShoppingListQuery) (ShoppingList, error)
+	Import(foodImport FoodImport) (ImportReport, error)
 }
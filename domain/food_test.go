@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"math"
+	"testing"
+)
+
+func TestFood_Scale(t *testing.T) {
+	egg := Ingredient{Name: "egg", PieceWeight: 50, Nutrients: Nutrients{Calories: 150}}
+	flour := Ingredient{Name: "flour", Nutrients: Nutrients{Calories: 350}}
+	milk := Ingredient{Name: "milk", Density: 1}
+	pancakes := Food{Name: "pancakes", Servings: 4, IngredientWeights: []IngredientWeight{
+		{Ingredient: egg, Weight: 0.1, Unit: "piece"},
+		{Ingredient: flour, Weight: 0.2, Unit: "g"},
+		{Ingredient: milk, Weight: 0.2, Unit: "cup"},
+	}}
+	pancakes.Scale(6)
+	var testData = []struct {
+		quantity Quantity
+		weight   float64
+	}{
+		// 2 pieces * 1.5
+		{Quantity{3, "piece"}, 0.15},
+		// 200 g * 1.5
+		{Quantity{300, "g"}, 0.3},
+		// 200 ml * 1.5 = 1.25 cups
+		{Quantity{1.25, "cup"}, 0.3},
+	}
+	for i, testcase := range testData {
+		ingredientWeight := pancakes.IngredientWeights[i]
+		if ingredientWeight.Quantity == nil || *ingredientWeight.Quantity != testcase.quantity {
+			t.Errorf("%v is not scaled to %v", ingredientWeight.Quantity, testcase.quantity)
+		}
+		if math.Abs(ingredientWeight.Weight-testcase.weight) > 1e-9 {
+			t.Errorf("weight %f is not %f", ingredientWeight.Weight, testcase.weight)
+		}
+	}
+	if pancakes.Servings != 6 || pancakes.Nutrition == nil || math.Abs(pancakes.Nutrition.Total.Calories-1275) > 1e-9 {
+		t.Error("nutrition is not scaled")
+	}
+}
+
+func TestFood_ScaleSmallWeight(t *testing.T) {
+	salt := Ingredient{Name: "salt", Nutrients: Nutrients{Salt: 100}}
+	soup := Food{Name: "soup", Servings: 3, IngredientWeights: []IngredientWeight{
+		{Ingredient: salt, Weight: 0.003},
+	}}
+	soup.Scale(4)
+	ingredientWeight := soup.IngredientWeights[0]
+	if ingredientWeight.Quantity == nil || *ingredientWeight.Quantity != (Quantity{0.004, "kg"}) {
+		t.Errorf("%v is not scaled to 4 g", ingredientWeight.Quantity)
+	}
+	if math.Abs(ingredientWeight.Weight-0.004) > 1e-9 || math.Abs(soup.Nutrition.Total.Salt-4) > 1e-9 {
+		t.Error("weight or nutrition is rounded ", ingredientWeight.Weight, soup.Nutrition.Total.Salt)
+	}
+}
+
+func TestFoodsByIngredientsQuery_Stock(t *testing.T) {
+	egg := Ingredient{Model: gorm.Model{ID: 1}, Name: "egg"}
+	milk := Ingredient{Model: gorm.Model{ID: 2}, Name: "milk", Density: 1.03}
+	query := FoodsByIngredientsQuery{Ingredients: []IngredientQuantity{
+		{Name: "eggs", Quantity: &Quantity{Amount: 2, Unit: "pieces"}},
+		{Name: "milk", Quantity: &Quantity{Amount: 200, Unit: "ml"}},
+		{Name: "flour"},
+	}}
+	stock, unresolved := query.Stock(map[string]Ingredient{"egg": egg, "milk": milk})
+	// eggs have no piece weight, they are not an unknown quantity
+	if len(unresolved) != 2 || unresolved[0] != "eggs" || unresolved[1] != "flour" {
+		t.Error("wrong unresolved ingredients ", unresolved)
+	}
+	if len(stock.Items) != 1 || stock.Items[0].Ingredient.ID != milk.ID || math.Abs(stock.Items[0].Weight-0.206) > 1e-9 {
+		t.Error("wrong stock ", stock.Items)
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"math"
+	"time"
+)
+
+// Favourite is a food marked by a user
+type Favourite struct {
+	gorm.Model
+	UserID uint `gorm:"uniqueIndex:idx_favourite"`
+	FoodID uint `gorm:"uniqueIndex:idx_favourite" validate:"nonzero"`
+	Food   Food `validate:"-"`
+}
+
+// CookedEvent is a food cooked by a user
+type CookedEvent struct {
+	gorm.Model
+	UserID   uint      `gorm:"index"`
+	FoodID   uint      `validate:"nonzero"`
+	Food     Food      `validate:"-"`
+	CookedAt time.Time // now if zero
+	Rating   uint      // from 1 to 5, 0 if not rated
+}
+
+const MaxRating = 5
+
+var InvalidRatingError = errors.New("rating must be from 1 to 5")
+
+// CheckRating checks the rating is from 1 to MaxRating or not set
+func (e *CookedEvent) CheckRating() error {
+	if e.Rating > MaxRating {
+		return InvalidRatingError
+	}
+	return nil
+}
+
+// FavouriteBoost is added to the score of a favourite food
+const FavouriteBoost = 0.5
+
+// ApplyHistory marks favourite and recently cooked foods of recommendations of the viewer,
+// favourites are boosted by FavouriteBoost if BoostFavourites is set, foods cooked within RecentDays
+// are lowered by up to 1, the more recently cooked the lower,
+// lastCooked is a map of last cooked times by food ids
+func (q FoodsByIngredientsQuery) ApplyHistory(foodRecommendations []FoodRecommendation, favouriteIDs []uint, lastCooked map[uint]time.Time) {
+	favourites := make(map[uint]bool, len(favouriteIDs))
+	for _, id := range favouriteIDs {
+		favourites[id] = true
+	}
+	date := q.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	for i := range foodRecommendations {
+		r := &foodRecommendations[i]
+		r.Favourite = favourites[r.Food.ID]
+		if r.Favourite && q.BoostFavourites {
+			r.Preference += FavouriteBoost
+		}
+		cookedAt, ok := lastCooked[r.Food.ID]
+		if !ok {
+			continue
+		}
+		r.LastCooked = &cookedAt
+		daysAgo := math.Max(math.Floor(date.Sub(cookedAt).Hours()/24), 0)
+		if period := float64(q.RecentDays); daysAgo < period {
+			r.Preference -= (period - daysAgo) / period
+		}
+	}
+}
+
+type HistoryRepository interface {
+	Favourites(userID uint) ([]Favourite, error)
+	// SaveFavourite does nothing if the food is already a favourite
+	SaveFavourite(favourite *Favourite) error
+	DeleteFavourite(userID uint, foodID uint) error
+	// History returns cooked events of the user, the latest first
+	History(userID uint) ([]CookedEvent, error)
+	SaveCookedEvent(event *CookedEvent) error
+	GetCookedEvent(id uint) (*CookedEvent, error)
+	DeleteCookedEvent(id uint) error
+}
+
+type HistoryService interface {
+	Favourites(userID uint) ([]Favourite, error)
+	AddFavourite(userID uint, foodID uint) (*Favourite, error)
+	RemoveFavourite(userID uint, foodID uint) error
+	History(userID uint) ([]CookedEvent, error)
+	// Cook saves a cooked event of the user
+	Cook(userID uint, event *CookedEvent) error
+	DeleteCookedEvent(userID uint, id uint) error
+}
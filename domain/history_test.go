@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func TestFoodsByIngredientsQuery_ApplyHistory(t *testing.T) {
+	date := time.Date(2020, 10, 10, 20, 0, 0, 0, time.UTC)
+	recommendations := []FoodRecommendation{
+		{Food: Food{Model: gorm.Model{ID: 1}}},
+		{Food: Food{Model: gorm.Model{ID: 2}}},
+		{Food: Food{Model: gorm.Model{ID: 3}}},
+		{Food: Food{Model: gorm.Model{ID: 4}}},
+	}
+	lastCooked := map[uint]time.Time{
+		2: date.Add(-time.Hour),
+		3: date.AddDate(0, 0, -3),
+		4: date.AddDate(0, 0, -5),
+	}
+	query := FoodsByIngredientsQuery{Date: date, BoostFavourites: true, RecentDays: 4}
+	query.ApplyHistory(recommendations, []uint{1, 4}, lastCooked)
+	expected := []float64{FavouriteBoost, -1, -0.25, FavouriteBoost}
+	for i, r := range recommendations {
+		if r.Preference != expected[i] {
+			t.Errorf("preference of %d is %f, not %f", r.Food.ID, r.Preference, expected[i])
+		}
+	}
+	if !recommendations[3].Favourite || recommendations[0].LastCooked != nil || recommendations[3].LastCooked == nil {
+		t.Error("wrong history marks")
+	}
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidImportError   = errors.New("import must be a json array or csv of food, ingredient, amount, unit rows")
+	ImportRowNameError   = errors.New("row must have a food and an ingredient")
+	ImportRowAmountError = errors.New("amount must not be negative")
+)
+
+// ImportRow is an ingredient of a food, foods and ingredients are found by names
+type ImportRow struct {
+	Food       string
+	Ingredient string
+	Amount     float64 // 0 if the quantity is unknown
+	Unit       string  // kg if empty
+}
+
+// Check checks names, the amount and the unit of the row
+func (r ImportRow) Check() error {
+	if strings.TrimSpace(r.Food) == "" || strings.TrimSpace(r.Ingredient) == "" {
+		return ImportRowNameError
+	}
+	if r.Amount < 0 {
+		return ImportRowAmountError
+	}
+	if r.Unit != "" {
+		if _, err := ParseUnit(r.Unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Quantity returns the quantity of the row, nil if the amount is unknown
+func (r ImportRow) Quantity() *Quantity {
+	if r.Amount == 0 {
+		return nil
+	}
+	unit := r.Unit
+	if unit == "" {
+		unit = CanonicalUnit
+	}
+	return &Quantity{Amount: r.Amount, Unit: unit}
+}
+
+// ReadImportRows reads a csv with an optional food,ingredient[,amount[,unit]] header if the media type is csv,
+// a json array of rows otherwise
+func ReadImportRows(r io.Reader, mediaType string) ([]ImportRow, error) {
+	if mediaType != CSVMediaType {
+		var rows []ImportRow
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, InvalidImportError
+		}
+		return rows, nil
+	}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, InvalidImportError
+	}
+	if len(records) != 0 && isImportHeader(records[0]) {
+		records = records[1:]
+	}
+	rows := make([]ImportRow, len(records))
+	for i, record := range records {
+		if len(record) < 2 || len(record) > 4 {
+			return nil, InvalidImportError
+		}
+		rows[i] = ImportRow{Food: record[0], Ingredient: record[1]}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			if rows[i].Amount, err = strconv.ParseFloat(strings.TrimSpace(record[2]), 64); err != nil {
+				return nil, InvalidImportError
+			}
+		}
+		if len(record) > 3 {
+			rows[i].Unit = strings.TrimSpace(record[3])
+		}
+	}
+	return rows, nil
+}
+
+// isImportHeader reports whether the record is food,ingredient[,amount[,unit]] in any case
+func isImportHeader(record []string) bool {
+	header := []string{"food", "ingredient", "amount", "unit"}
+	if len(record) < 2 || len(record) > len(header) {
+		return false
+	}
+	for i, name := range record {
+		if !strings.EqualFold(strings.TrimSpace(name), header[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+type ImportStatus string
+
+const (
+	// ImportCreated means that the ingredient is added to the food
+	ImportCreated ImportStatus = "created"
+	// ImportUpdated means that the quantity of the food ingredient is changed
+	ImportUpdated ImportStatus = "updated"
+	// ImportSkipped means that the food already has the ingredient in the quantity or the amount of the row is unknown
+	ImportSkipped ImportStatus = "skipped"
+	// ImportFailed means that the row is not imported because of the error
+	ImportFailed ImportStatus = "failed"
+)
+
+// ImportRowReport is the result of an imported row, rows are numbered from 1
+type ImportRowReport struct {
+	Row          int
+	Food         string
+	Ingredient   string
+	Status       ImportStatus
+	FoodID       uint   `json:",omitempty"`
+	IngredientID uint   `json:",omitempty"`
+	Error        string `json:",omitempty"`
+	// FoodCreated and IngredientCreated are set if the row created them
+	FoodCreated       bool `json:",omitempty"`
+	IngredientCreated bool `json:",omitempty"`
+}
+
+type ImportReport struct {
+	Rows                              []ImportRowReport
+	Created, Updated, Skipped, Failed int
+}
+
+// Add appends the row report and counts its status
+func (r *ImportReport) Add(row ImportRowReport) {
+	r.Rows = append(r.Rows, row)
+	switch row.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+}
+
+// FoodImport is a bulk import of ingredients of foods, missing foods are created with the owner and the visibility,
+// foods of the owner with the same names in any case are updated, ingredients are found by names and aliases or created
+type FoodImport struct {
+	Rows       []ImportRow
+	OwnerID    uint // 0 for shared foods
+	Visibility Visibility
+}
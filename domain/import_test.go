@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadImportRows(t *testing.T) {
+	csv := "food,ingredient,amount,unit\nomelette,egg,2,pieces\n\"pancakes, thin\",milk, 200 ,ml\nomelette,salt\n"
+	rows, err := ReadImportRows(strings.NewReader(csv), CSVMediaType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ImportRow{
+		{Food: "omelette", Ingredient: "egg", Amount: 2, Unit: "pieces"},
+		{Food: "pancakes, thin", Ingredient: "milk", Amount: 200, Unit: "ml"},
+		{Food: "omelette", Ingredient: "salt"},
+	}
+	if len(rows) != len(expected) {
+		t.Fatal("wrong rows ", rows)
+	}
+	for i := range expected {
+		if rows[i] != expected[i] {
+			t.Errorf("row %d is %v, not %v", i+1, rows[i], expected[i])
+		}
+	}
+	// check a food named food is not a header
+	rows, err = ReadImportRows(strings.NewReader("Food, Ingredient\nfood,salt,5,g\n"), CSVMediaType)
+	if err != nil || len(rows) != 1 || rows[0].Food != "food" || rows[0].Amount != 5 {
+		t.Error("wrong rows after header ", rows, err)
+	}
+	if _, err := ReadImportRows(strings.NewReader("omelette,egg,two"), CSVMediaType); err != InvalidImportError {
+		t.Error("err is not equal error ", InvalidImportError)
+	}
+	rows, err = ReadImportRows(strings.NewReader(`[{"food": "omelette", "ingredient": "egg", "amount": 0.1}]`), "")
+	if err != nil || len(rows) != 1 || rows[0].Amount != 0.1 || rows[0].Quantity().Unit != CanonicalUnit {
+		t.Error("wrong json rows ", rows, err)
+	}
+}
+
+func TestImportRow_Check(t *testing.T) {
+	rows := map[ImportRow]error{
+		{Food: "omelette", Ingredient: "egg", Amount: 2, Unit: "piece"}:  nil,
+		{Food: " ", Ingredient: "egg"}:                                   ImportRowNameError,
+		{Food: "omelette", Ingredient: "egg", Amount: -1}:                ImportRowAmountError,
+		{Food: "omelette", Ingredient: "egg", Amount: 1, Unit: "bucket"}: UnknownUnitError,
+	}
+	for row, expected := range rows {
+		if err := row.Check(); err != expected {
+			t.Errorf("%v: err %v is not equal error %v", row, err, expected)
+		}
+	}
+}
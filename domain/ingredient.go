@@ -2,12 +2,34 @@ package domain
 
 import (
 	"gorm.io/gorm"
+	"math"
+	"strings"
+	"time"
 )
 
 type Ingredient struct {
 	gorm.Model
-	Name     string  `validate:"nonzero"`
-	Calories float64 `validate:"min=0"`
+	Name           string            `validate:"nonzero"`
+	NormalizedName string            `gorm:"index" json:"-"`
+	Nutrients                        // per 100 g
+	Density        float64           `validate:"min=0"` // g/ml, 0 if unknown
+	PieceWeight    float64           `validate:"min=0"` // g, 0 if unknown
+	Aliases        []IngredientAlias // other names of the ingredient
+	Allergens      Allergens         `gorm:"default:0"`
+	Diets          Diets             `gorm:"default:0"` // compatible diets
+	// Aisle is the store aisle where the ingredient is bought, DefaultAisle if empty
+	Aisle string `gorm:"default:''"`
+}
+
+// BeforeSave normalizes the name, a vegan ingredient is vegetarian
+func (i *Ingredient) BeforeSave(tx *gorm.DB) error {
+	if i.Name != "" {
+		i.NormalizedName = NormalizeIngredientName(i.Name)
+	}
+	if i.Diets&Vegan != 0 {
+		i.Diets |= Vegetarian
+	}
+	return nil
 }
 
 func (i *Ingredient) Equal(i2 interface{}) bool {
@@ -27,16 +49,165 @@ func (i *Ingredient) Equal(i2 interface{}) bool {
 		i.CreatedAt.Equal(other.CreatedAt)
 }
 
+type IngredientAlias struct {
+	gorm.Model
+	IngredientID   uint
+	Name           string `validate:"nonzero"`
+	NormalizedName string `gorm:"index" json:"-"`
+}
+
+func (a *IngredientAlias) BeforeSave(tx *gorm.DB) error {
+	if a.Name != "" {
+		a.NormalizedName = NormalizeIngredientName(a.Name)
+	}
+	return nil
+}
+
+// NormalizeIngredientName makes a lookup key of the name:
+// whitespaces are trimmed and collapsed, case is folded and the last word is singularized
+func NormalizeIngredientName(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// singular is a simple plural stemming, it is not always right but it is the same for all forms of a word
+func singular(word string) string {
+	switch {
+	case len(word) > 3 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 2 && strings.HasSuffix(word, "ie"):
+		return word[:len(word)-2] + "y"
+	case len(word) > 3 && (strings.HasSuffix(word, "oes") ||
+		strings.HasSuffix(word, "ches") ||
+		strings.HasSuffix(word, "shes") ||
+		strings.HasSuffix(word, "sses") ||
+		strings.HasSuffix(word, "xes")):
+		return word[:len(word)-2]
+	// the singular form of the same word drops "e" to match the plural
+	case len(word) > 2 && (strings.HasSuffix(word, "oe") ||
+		strings.HasSuffix(word, "che") ||
+		strings.HasSuffix(word, "she") ||
+		strings.HasSuffix(word, "sse") ||
+		strings.HasSuffix(word, "xe")):
+		return word[:len(word)-1]
+	case len(word) > 2 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") &&
+		!strings.HasSuffix(word, "is"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
 type IngredientWeight struct {
 	gorm.Model
 	FoodID       uint
 	IngredientID uint
 	Ingredient   Ingredient
 	Weight       float64 //kg
+	// Unit is the unit of the quantity as it was entered, kg if empty
+	Unit string
+	// Quantity is the weight in the unit, it replaces the weight when it is saved
+	Quantity *Quantity `gorm:"-"`
+}
+
+// ApplyQuantity converts the quantity to the canonical weight
+func (iw *IngredientWeight) ApplyQuantity(ingredient Ingredient) error {
+	if iw.Quantity == nil {
+		return nil
+	}
+	weight, err := iw.Quantity.Kilograms(ingredient)
+	if err != nil {
+		return err
+	}
+	unit, _ := ParseUnit(iw.Quantity.Unit)
+	iw.Weight = weight
+	iw.Unit = unit.Name
+	return nil
+}
+
+// ConvertQuantity sets the quantity of the weight in the unit, the unit of the weight if empty,
+// the quantity is in kg if the unit can't be converted
+func (iw *IngredientWeight) ConvertQuantity(unit string) error {
+	if unit == "" {
+		unit = iw.Unit
+	}
+	if unit == "" {
+		unit = CanonicalUnit
+	}
+	quantity, err := QuantityOf(iw.Weight, unit, iw.Ingredient)
+	if err == UnconvertibleUnitError {
+		quantity, err = QuantityOf(iw.Weight, CanonicalUnit, iw.Ingredient)
+	}
+	if err != nil {
+		return err
+	}
+	iw.Quantity = &quantity
+	return nil
+}
+
+// SameQuantity compares weights and units of ingredient weights, an empty unit is kg
+func (iw IngredientWeight) SameQuantity(other IngredientWeight) bool {
+	unit, otherUnit := iw.Unit, other.Unit
+	if unit == "" {
+		unit = CanonicalUnit
+	}
+	if otherUnit == "" {
+		otherUnit = CanonicalUnit
+	}
+	return unit == otherUnit && math.Abs(iw.Weight-other.Weight) < 1e-9
+}
+
+// IngredientSubstitute means that the substitute can replace the ingredient in foods
+type IngredientSubstitute struct {
+	gorm.Model
+	IngredientID uint
+	Ingredient   Ingredient `validate:"-"`
+	SubstituteID uint       `validate:"nonzero"`
+	Substitute   Ingredient `validate:"-"`
+	Ratio        float64    `validate:"min=0"` // substitute weight per ingredient weight, 1 if zero
+	Note         string
+}
+
+// SubstituteWeight returns the substitute weight replacing the ingredient weight
+func (s *IngredientSubstitute) SubstituteWeight(weight float64) float64 {
+	if s.Ratio == 0 {
+		return weight
+	}
+	return weight * s.Ratio
+}
+
+// IngredientListQuery filters ingredients, all filters are optional
+type IngredientListQuery struct {
+	Page
+	NameContains string
+	CreatedAfter *time.Time
+	MinCalories  *float64
+	MaxCalories  *float64
+}
+
+var IngredientSortFields = []string{"id", "name", "createdAt", "calories"}
+
+type IngredientList struct {
+	Ingredients []Ingredient
+	Total       int64
+	Offset      int
+	Limit       int
 }
 
 type IngredientRepository interface {
 	CrudRepository
+	Substitutes(ingredientID uint) ([]IngredientSubstitute, error)
+	GetSubstitute(id uint) (*IngredientSubstitute, error)
+	SaveSubstitute(substitute *IngredientSubstitute) error
+	UpdateSubstitute(id uint, substitute *IngredientSubstitute) error
+	DeleteSubstitute(id uint) error
+	Search(query string, limit int) ([]IngredientMatch, error)
+	List(query IngredientListQuery) (IngredientList, error)
 }
 
 type IngredientService interface {
@@ -44,4 +215,10 @@ type IngredientService interface {
 	Update(id uint, ingredient *Ingredient) error
 	Delete(id uint) error
 	Get(id uint) (*Ingredient, error)
+	Substitutes(ingredientID uint) ([]IngredientSubstitute, error)
+	SaveSubstitute(ingredientID uint, substitute *IngredientSubstitute) error
+	UpdateSubstitute(ingredientID uint, id uint, substitute *IngredientSubstitute) error
+	DeleteSubstitute(ingredientID uint, id uint) error
+	Search(query string, limit int) ([]IngredientMatch, error)
+	List(query IngredientListQuery) (IngredientList, error)
 }
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestNormalizeIngredientName(t *testing.T) {
+	var testData = []struct {
+		names      []string
+		normalized string
+	}{
+		{[]string{"egg", "Eggs", " EGG ", "eggs"}, "egg"},
+		{[]string{"tomato", "Tomatoes"}, "tomato"},
+		{[]string{"berry", "berries"}, "berry"},
+		{[]string{"cookie", "cookies"}, "cooky"},
+		{[]string{"peach", "peaches"}, "peach"},
+		{[]string{"green  onion", "Green Onions"}, "green onion"},
+		{[]string{"couscous"}, "couscous"},
+		{[]string{"glass", "glasses"}, "glass"},
+		{[]string{"quiche", "quiches"}, "quich"},
+		{[]string{"mousse", "mousses"}, "mouss"},
+		{[]string{"sloe", "sloes"}, "slo"},
+		{[]string{"cheese", "cheeses"}, "cheese"},
+	}
+	for _, testcase := range testData {
+		for _, name := range testcase.names {
+			if normalized := NormalizeIngredientName(name); normalized != testcase.normalized {
+				t.Errorf("\"%s\" is normalized to \"%s\", not \"%s\"", name, normalized, testcase.normalized)
+			}
+		}
+	}
+}
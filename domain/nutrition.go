@@ -0,0 +1,105 @@
+package domain
+
+// Nutrients are nutrition facts, energy is in kcal and others are in g
+type Nutrients struct {
+	Calories     float64 `validate:"min=0"` //kcal
+	Protein      float64 `validate:"min=0"`
+	Fat          float64 `validate:"min=0"`
+	Carbohydrate float64 `validate:"min=0"`
+	Fibre        float64 `validate:"min=0"`
+	Sugar        float64 `validate:"min=0"`
+	Salt         float64 `validate:"min=0"`
+}
+
+func (n Nutrients) Add(other Nutrients) Nutrients {
+	return Nutrients{
+		Calories:     n.Calories + other.Calories,
+		Protein:      n.Protein + other.Protein,
+		Fat:          n.Fat + other.Fat,
+		Carbohydrate: n.Carbohydrate + other.Carbohydrate,
+		Fibre:        n.Fibre + other.Fibre,
+		Sugar:        n.Sugar + other.Sugar,
+		Salt:         n.Salt + other.Salt,
+	}
+}
+
+func (n Nutrients) Scale(k float64) Nutrients {
+	return Nutrients{
+		Calories:     n.Calories * k,
+		Protein:      n.Protein * k,
+		Fat:          n.Fat * k,
+		Carbohydrate: n.Carbohydrate * k,
+		Fibre:        n.Fibre * k,
+		Sugar:        n.Sugar * k,
+		Salt:         n.Salt * k,
+	}
+}
+
+// IsZero means that there is no nutrient data
+func (n Nutrients) IsZero() bool {
+	return n == Nutrients{}
+}
+
+// FoodNutrition is nutrients of a food computed from its ingredients
+type FoodNutrition struct {
+	Servings   uint
+	Total      Nutrients
+	PerServing Nutrients
+}
+
+// DefaultReferenceIntake is the daily reference intake of an average adult
+var DefaultReferenceIntake = Nutrients{
+	Calories:     2000,
+	Protein:      50,
+	Fat:          70,
+	Carbohydrate: 260,
+	Fibre:        25,
+	Sugar:        90,
+	Salt:         6,
+}
+
+// Percent returns nutrients in percents of the reference, 0 if the reference is 0
+func (n Nutrients) Percent(reference Nutrients) Nutrients {
+	percent := func(value float64, referenceValue float64) float64 {
+		if referenceValue == 0 {
+			return 0
+		}
+		return value / referenceValue * 100
+	}
+	return Nutrients{
+		Calories:     percent(n.Calories, reference.Calories),
+		Protein:      percent(n.Protein, reference.Protein),
+		Fat:          percent(n.Fat, reference.Fat),
+		Carbohydrate: percent(n.Carbohydrate, reference.Carbohydrate),
+		Fibre:        percent(n.Fibre, reference.Fibre),
+		Sugar:        percent(n.Sugar, reference.Sugar),
+		Salt:         percent(n.Salt, reference.Salt),
+	}
+}
+
+// NutritionReport is food nutrition compared with a daily reference intake
+type NutritionReport struct {
+	FoodNutrition
+	ReferenceIntake Nutrients
+	// PerServingIntake is per serving nutrients in percents of the reference intake
+	PerServingIntake Nutrients
+	// IngredientsWithoutData have no nutrient data, so totals are incomplete
+	IngredientsWithoutData []Ingredient
+	Incomplete             bool
+}
+
+func (f *Food) NutritionReport(referenceIntake Nutrients) NutritionReport {
+	report := NutritionReport{
+		FoodNutrition:          f.ComputeNutrition(),
+		ReferenceIntake:        referenceIntake,
+		IngredientsWithoutData: make([]Ingredient, 0),
+	}
+	report.PerServingIntake = report.PerServing.Percent(referenceIntake)
+	for _, ingredientWeight := range f.IngredientWeights {
+		if ingredientWeight.Ingredient.Nutrients.IsZero() {
+			report.IngredientsWithoutData = append(report.IngredientsWithoutData, ingredientWeight.Ingredient)
+		}
+	}
+	report.Incomplete = len(report.IngredientsWithoutData) > 0
+	return report
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Pantry is a set of ingredients stored at home
+type Pantry struct {
+	gorm.Model
+	OwnerID uint   `gorm:"index"` // 0 if the pantry is shared
+	Name    string `validate:"nonzero"`
+	Items   []PantryItem
+}
+
+// VisibleTo reports whether the user can use the pantry
+func (p *Pantry) VisibleTo(userID uint) bool {
+	return p.OwnerID == 0 || p.OwnerID == userID
+}
+
+func (p *Pantry) Equal(pantry interface{}) bool {
+	p2, ok := pantry.(*Pantry)
+	if !ok {
+		return false
+	}
+	if p2 == p || p2.ID == p.ID {
+		return true
+	}
+	return p2.Name == p.Name
+}
+
+// FoodsByIngredientsQuery makes a query for foods which can be cooked from the pantry items
+func (p *Pantry) FoodsByIngredientsQuery() FoodsByIngredientsQuery {
+	query := FoodsByIngredientsQuery{
+		Ingredients: make([]IngredientQuantity, len(p.Items)),
+		ViewerID:    p.OwnerID,
+	}
+	for i, item := range p.Items {
+		query.Ingredients[i] = IngredientQuantity{
+			Name:       item.Ingredient.Name,
+			Weight:     item.Weight,
+			BestBefore: item.ExpiresAt,
+		}
+	}
+	return query
+}
+
+type PantryItem struct {
+	gorm.Model
+	PantryID     uint
+	IngredientID uint       `validate:"nonzero"`
+	Ingredient   Ingredient `validate:"-"`
+	Weight       float64    //kg, 0 if the quantity is unknown
+	ExpiresAt    *time.Time // optional
+}
+
+type PantryRepository interface {
+	CrudRepository
+}
+
+type PantryService interface {
+	Save(pantry *Pantry) error
+	Update(id uint, pantry *Pantry) error
+	Delete(id uint) error
+	Get(id uint) (*Pantry, error)
+	FindFoods(id uint) (FoodsByIngredientsResult, error)
+}
@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+type Meal string
+
+const (
+	Breakfast Meal = "breakfast"
+	Lunch     Meal = "lunch"
+	Dinner    Meal = "dinner"
+)
+
+// Meals are meals of a day in their order
+var Meals = []Meal{Breakfast, Lunch, Dinner}
+
+func (m Meal) order() int {
+	for i, meal := range Meals {
+		if m == meal {
+			return i
+		}
+	}
+	return -1
+}
+
+const (
+	DefaultPlanDays = 7
+	MaxPlanDays     = 31
+)
+
+var (
+	InvalidMealError     = errors.New("meal must be breakfast, lunch or dinner")
+	InvalidPlanDaysError = errors.New("plan must have a start date and from 1 to 31 days")
+	SlotOutOfPlanError   = errors.New("slot date is out of the plan")
+	DuplicateSlotError   = errors.New("slot is already planned")
+)
+
+// MealPlan is a plan of meals for a number of days from the start date
+type MealPlan struct {
+	gorm.Model
+	OwnerID   uint      `gorm:"index"`
+	Name      string    `validate:"nonzero"`
+	StartDate time.Time // the time of day is dropped
+	Days      uint      `gorm:"default:7"` // DefaultPlanDays if zero
+	Slots     []MealSlot
+}
+
+// MealSlot is a food planned for a meal of a day
+type MealSlot struct {
+	gorm.Model
+	MealPlanID uint
+	Date       time.Time // the time of day is dropped
+	Meal       Meal
+	FoodID     uint `validate:"nonzero"`
+	Food       Food `validate:"-"`
+	Servings   uint // servings of the food if zero
+}
+
+// PlanDate drops the time of day of the date
+func PlanDate(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// VisibleTo reports whether the user can use the plan, plans are seen only by owners
+func (p *MealPlan) VisibleTo(userID uint) bool {
+	return p.OwnerID == userID
+}
+
+func (p *MealPlan) Equal(plan interface{}) bool {
+	p2, ok := plan.(*MealPlan)
+	if !ok {
+		return false
+	}
+	if p2 == p || p2.ID == p.ID {
+		return true
+	}
+	return p2.Name == p.Name && p2.StartDate.Equal(p.StartDate)
+}
+
+// EndDate is the day after the last day of the plan
+func (p *MealPlan) EndDate() time.Time {
+	return p.StartDate.AddDate(0, 0, int(p.Days))
+}
+
+// CheckDays drops the time of the start date, sets default days and checks the plan period
+func (p *MealPlan) CheckDays() error {
+	if p.Days == 0 {
+		p.Days = DefaultPlanDays
+	}
+	if p.StartDate.IsZero() || p.Days > MaxPlanDays {
+		return InvalidPlanDaysError
+	}
+	p.StartDate = PlanDate(p.StartDate)
+	return nil
+}
+
+// CheckSlots drops the time of slot dates, checks slots are in the plan period and not duplicated,
+// and sorts them by dates and meals
+func (p *MealPlan) CheckSlots() error {
+	planned := make(map[time.Time]map[Meal]bool)
+	for i := range p.Slots {
+		slot := &p.Slots[i]
+		if slot.Meal.order() < 0 {
+			return InvalidMealError
+		}
+		slot.Date = PlanDate(slot.Date)
+		if slot.Date.Before(p.StartDate) || !slot.Date.Before(p.EndDate()) {
+			return SlotOutOfPlanError
+		}
+		if planned[slot.Date] == nil {
+			planned[slot.Date] = make(map[Meal]bool)
+		}
+		if planned[slot.Date][slot.Meal] {
+			return DuplicateSlotError
+		}
+		planned[slot.Date][slot.Meal] = true
+	}
+	p.SortSlots()
+	return nil
+}
+
+// SortSlots orders slots by dates and meals
+func (p *MealPlan) SortSlots() {
+	sort.SliceStable(p.Slots, func(i, j int) bool {
+		a, b := p.Slots[i], p.Slots[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.Meal.order() < b.Meal.order()
+	})
+}
+
+func (p *MealPlan) AfterFind(tx *gorm.DB) error {
+	p.SortSlots()
+	return nil
+}
+
+// FreeSlots returns slots of the meals which are not planned yet, ordered by dates and meals
+func (p *MealPlan) FreeSlots(meals []Meal) []MealSlot {
+	planned := make(map[time.Time]map[Meal]bool)
+	for _, slot := range p.Slots {
+		date := PlanDate(slot.Date)
+		if planned[date] == nil {
+			planned[date] = make(map[Meal]bool)
+		}
+		planned[date][slot.Meal] = true
+	}
+	free := make([]MealSlot, 0)
+	for date := p.StartDate; date.Before(p.EndDate()); date = date.AddDate(0, 0, 1) {
+		for _, meal := range Meals {
+			if !containsMeal(meals, meal) || planned[date][meal] {
+				continue
+			}
+			free = append(free, MealSlot{MealPlanID: p.ID, Date: date, Meal: meal})
+		}
+	}
+	return free
+}
+
+func containsMeal(meals []Meal, meal Meal) bool {
+	for _, m := range meals {
+		if m == meal {
+			return true
+		}
+	}
+	return false
+}
+
+// Autofill plans recommended foods for free slots of the meals, all meals if empty,
+// the best ranked food which is not planned yet is taken for every slot, so foods are not repeated
+// and slots are left free when recommendations run out, servings of foods are used if servings is zero,
+// it returns added slots
+func (p *MealPlan) Autofill(foodRecommendations []FoodRecommendation, meals []Meal, servings uint) ([]MealSlot, error) {
+	if len(meals) == 0 {
+		meals = Meals
+	}
+	for _, meal := range meals {
+		if meal.order() < 0 {
+			return nil, InvalidMealError
+		}
+	}
+	planned := make(map[uint]bool)
+	for _, slot := range p.Slots {
+		planned[slot.FoodID] = true
+	}
+	added := make([]MealSlot, 0)
+	next := 0
+	for _, slot := range p.FreeSlots(meals) {
+		for next < len(foodRecommendations) && planned[foodRecommendations[next].Food.ID] {
+			next++
+		}
+		if next == len(foodRecommendations) {
+			break
+		}
+		food := foodRecommendations[next].Food
+		planned[food.ID] = true
+		slot.FoodID = food.ID
+		slot.Food = food
+		slot.Servings = servings
+		if slot.Servings == 0 {
+			slot.Servings = food.Servings
+		}
+		added = append(added, slot)
+	}
+	p.Slots = append(p.Slots, added...)
+	p.SortSlots()
+	return added, nil
+}
+
+// MealPlanAutofill selects foods for free slots of a plan by available ingredients
+type MealPlanAutofill struct {
+	FoodsByIngredientsQuery
+	// Meals are meals to fill, all meals if empty
+	Meals []Meal
+	// Servings of every added slot, servings of foods if zero
+	Servings uint
+}
+
+type MealPlanRepository interface {
+	CrudRepository
+	// List returns plans of the owner, the latest first
+	List(ownerID uint) ([]MealPlan, error)
+}
+
+type MealPlanService interface {
+	Save(plan *MealPlan) error
+	Update(id uint, plan *MealPlan) error
+	Delete(id uint) error
+	Get(id uint) (*MealPlan, error)
+	List(ownerID uint) ([]MealPlan, error)
+	// Autofill plans foods for free slots and returns the plan
+	Autofill(id uint, autofill MealPlanAutofill) (*MealPlan, error)
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func TestMealPlan_CheckSlots(t *testing.T) {
+	monday := time.Date(2020, 10, 5, 0, 0, 0, 0, time.UTC)
+	var testData = []struct {
+		slots []MealSlot
+		err   error
+	}{
+		{[]MealSlot{{Date: monday.Add(12 * time.Hour), Meal: Dinner}, {Date: monday, Meal: Breakfast}}, nil},
+		{[]MealSlot{{Date: monday, Meal: "supper"}}, InvalidMealError},
+		{[]MealSlot{{Date: monday.AddDate(0, 0, -1), Meal: Lunch}}, SlotOutOfPlanError},
+		{[]MealSlot{{Date: monday.AddDate(0, 0, 7), Meal: Lunch}}, SlotOutOfPlanError},
+		{[]MealSlot{{Date: monday, Meal: Lunch}, {Date: monday.Add(time.Hour), Meal: Lunch}}, DuplicateSlotError},
+	}
+	for i, testcase := range testData {
+		plan := MealPlan{StartDate: monday.Add(8 * time.Hour), Slots: testcase.slots}
+		if err := plan.CheckDays(); err != nil {
+			t.Fatal(err)
+		}
+		if err := plan.CheckSlots(); err != testcase.err {
+			t.Errorf("%d: err %v is not %v", i, err, testcase.err)
+		}
+	}
+	plan := MealPlan{StartDate: monday, Slots: testData[0].slots}
+	plan.CheckDays()
+	plan.CheckSlots()
+	if plan.Days != DefaultPlanDays || plan.Slots[0].Meal != Breakfast || !plan.Slots[1].Date.Equal(monday) {
+		t.Error("slots are not sorted by dates and meals")
+	}
+	if err := (&MealPlan{}).CheckDays(); err != InvalidPlanDaysError {
+		t.Error("err is not equal error ", InvalidPlanDaysError)
+	}
+}
+
+func TestMealPlan_Autofill(t *testing.T) {
+	monday := time.Date(2020, 10, 5, 0, 0, 0, 0, time.UTC)
+	food := func(id uint) Food {
+		return Food{Model: gorm.Model{ID: id}, Servings: 4}
+	}
+	plan := MealPlan{
+		StartDate: monday,
+		Days:      3,
+		Slots:     []MealSlot{{Date: monday, Meal: Dinner, FoodID: 1}},
+	}
+	recommendations := []FoodRecommendation{{Food: food(1)}, {Food: food(2)}, {Food: food(3)}}
+	if _, err := plan.Autofill(recommendations, []Meal{"supper"}, 0); err != InvalidMealError {
+		t.Error("err is not equal error ", InvalidMealError)
+	}
+	added, err := plan.Autofill(recommendations, []Meal{Lunch, Dinner}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first food is planned, two foods are left for five free slots
+	if len(added) != 2 || len(plan.Slots) != 3 {
+		t.Fatalf("%d slots are added", len(added))
+	}
+	if added[0].FoodID != 2 || !added[0].Date.Equal(monday) || added[0].Meal != Lunch || added[0].Servings != 4 {
+		t.Error("wrong first slot")
+	}
+	if added[1].FoodID != 3 || !added[1].Date.Equal(monday.AddDate(0, 0, 1)) || added[1].Meal != Lunch {
+		t.Error("wrong second slot")
+	}
+	if plan.Slots[1].Meal != Dinner || plan.Slots[2].FoodID != 3 {
+		t.Error("slots are not sorted")
+	}
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+type UnitKind int
+
+const (
+	Mass   UnitKind = iota // base unit is g
+	Volume                 // base unit is ml
+	Count                  // base unit is piece
+)
+
+// Unit is a unit of measure, factor converts it to the base unit of its kind
+type Unit struct {
+	Name   string
+	Kind   UnitKind
+	Factor float64
+}
+
+// CanonicalUnit is the unit of stored ingredient weights
+const CanonicalUnit = "kg"
+
+var units = make(map[string]Unit)
+
+// RegisterUnit adds the unit to the conversion registry by its name and aliases
+func RegisterUnit(unit Unit, aliases ...string) {
+	for _, name := range append([]string{unit.Name}, aliases...) {
+		units[strings.ToLower(name)] = unit
+	}
+}
+
+func init() {
+	RegisterUnit(Unit{"mg", Mass, 0.001}, "milligram", "milligrams")
+	RegisterUnit(Unit{"g", Mass, 1}, "gram", "grams")
+	RegisterUnit(Unit{CanonicalUnit, Mass, 1000}, "kilogram", "kilograms")
+	RegisterUnit(Unit{"oz", Mass, 28.349523125}, "ounce", "ounces")
+	RegisterUnit(Unit{"lb", Mass, 453.59237}, "pound", "pounds")
+	RegisterUnit(Unit{"ml", Volume, 1}, "millilitre", "millilitres")
+	RegisterUnit(Unit{"l", Volume, 1000}, "litre", "litres")
+	RegisterUnit(Unit{"tsp", Volume, 5}, "teaspoon", "teaspoons")
+	RegisterUnit(Unit{"tbsp", Volume, 15}, "tablespoon", "tablespoons")
+	RegisterUnit(Unit{"cup", Volume, 240}, "cups")
+	RegisterUnit(Unit{"piece", Count, 1}, "pieces", "pc", "pcs")
+}
+
+var UnknownUnitError = errors.New("unknown unit")
+
+// UnconvertibleUnitError means that the ingredient has no density or piece weight for the unit
+var UnconvertibleUnitError = errors.New("unit can't be converted for the ingredient")
+
+// ParseUnit returns a registered unit by name or alias
+func ParseUnit(name string) (Unit, error) {
+	unit, ok := units[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Unit{}, UnknownUnitError
+	}
+	return unit, nil
+}
+
+// Quantity is an amount of an ingredient in a unit
+type Quantity struct {
+	Amount float64 `validate:"min=0"`
+	Unit   string
+}
+
+// gramsPerBaseUnit returns grams in g, ml or piece of the ingredient
+func gramsPerBaseUnit(kind UnitKind, ingredient Ingredient) (float64, error) {
+	switch kind {
+	case Volume:
+		if ingredient.Density <= 0 {
+			return 0, UnconvertibleUnitError
+		}
+		return ingredient.Density, nil
+	case Count:
+		if ingredient.PieceWeight <= 0 {
+			return 0, UnconvertibleUnitError
+		}
+		return ingredient.PieceWeight, nil
+	}
+	return 1, nil
+}
+
+// Kilograms converts the quantity of the ingredient to kg
+func (q Quantity) Kilograms(ingredient Ingredient) (float64, error) {
+	unit, err := ParseUnit(q.Unit)
+	if err != nil {
+		return 0, err
+	}
+	grams, err := gramsPerBaseUnit(unit.Kind, ingredient)
+	if err != nil {
+		return 0, err
+	}
+	return q.Amount * unit.Factor * grams / 1000, nil
+}
+
+// QuantityOf converts the weight (kg) of the ingredient to the unit
+func QuantityOf(weight float64, unitName string, ingredient Ingredient) (Quantity, error) {
+	unit, err := ParseUnit(unitName)
+	if err != nil {
+		return Quantity{}, err
+	}
+	grams, err := gramsPerBaseUnit(unit.Kind, ingredient)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Amount: weight * 1000 / grams / unit.Factor, Unit: unit.Name}, nil
+}
+
+// Round rounds the amount to a step sensible for the unit, a positive amount is at least one step,
+// amounts less than one kg or l are rounded as g or ml
+func (q Quantity) Round() Quantity {
+	unit, err := ParseUnit(q.Unit)
+	if err != nil || q.Amount <= 0 {
+		return q
+	}
+	if q.Amount < 1 && (unit.Name == "kg" || unit.Name == "l") {
+		small := "g"
+		if unit.Name == "l" {
+			small = "ml"
+		}
+		rounded := Quantity{Amount: q.Amount * 1000, Unit: small}.Round()
+		return Quantity{Amount: rounded.Amount / 1000, Unit: unit.Name}
+	}
+	var step float64
+	switch unit.Name {
+	case "g", "ml":
+		switch {
+		case q.Amount < 10:
+			step = 0.5
+		case q.Amount < 100:
+			step = 1
+		default:
+			step = 5
+		}
+	case "kg", "l":
+		step = 0.01
+	case "mg":
+		step = 1
+	case "lb":
+		step = 0.05
+	case "oz", "tsp", "tbsp", "cup":
+		step = 0.25
+	case "piece":
+		step = 1
+		if q.Amount < 2 {
+			step = 0.5
+		}
+	default:
+		step = 0.01
+	}
+	rounded := math.Round(q.Amount/step) * step
+	if rounded < step {
+		rounded = step
+	}
+	return Quantity{Amount: rounded, Unit: unit.Name}
+}
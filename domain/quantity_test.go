@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantity_Kilograms(t *testing.T) {
+	egg := Ingredient{Name: "egg", PieceWeight: 50}
+	milk := Ingredient{Name: "milk", Density: 1.03}
+	var testData = []struct {
+		quantity   Quantity
+		ingredient Ingredient
+		kilograms  float64
+		err        error
+	}{
+		{Quantity{250, "g"}, milk, 0.25, nil},
+		{Quantity{2, "Pieces"}, egg, 0.1, nil},
+		{Quantity{1, "cup"}, milk, 0.2472, nil},
+		{Quantity{1, "lb"}, egg, 0.45359237, nil},
+		{Quantity{1, "cup"}, egg, 0, UnconvertibleUnitError},
+		{Quantity{1, "bucket"}, milk, 0, UnknownUnitError},
+	}
+	for _, testcase := range testData {
+		kilograms, err := testcase.quantity.Kilograms(testcase.ingredient)
+		if err != testcase.err {
+			t.Errorf("%v: err is not equal error %v", testcase.quantity, testcase.err)
+		}
+		if math.Abs(kilograms-testcase.kilograms) > 1e-9 {
+			t.Errorf("%v is %f kg, not %f kg", testcase.quantity, kilograms, testcase.kilograms)
+		}
+	}
+}
+
+func TestQuantityOf(t *testing.T) {
+	egg := Ingredient{Name: "egg", PieceWeight: 50}
+	quantity, err := QuantityOf(0.15, "pcs", egg)
+	if err != nil {
+		t.Error(err)
+	}
+	if quantity.Unit != "piece" || math.Abs(quantity.Amount-3) > 1e-9 {
+		t.Error("wrong quantity")
+	}
+}
+
+func TestQuantity_Round(t *testing.T) {
+	var testData = []struct {
+		quantity Quantity
+		rounded  Quantity
+	}{
+		{Quantity{3.3, "g"}, Quantity{3.5, "g"}},
+		{Quantity{42.4, "grams"}, Quantity{42, "g"}},
+		{Quantity{312, "g"}, Quantity{310, "g"}},
+		{Quantity{0.1, "tsp"}, Quantity{0.25, "tsp"}},
+		{Quantity{1.4, "piece"}, Quantity{1.5, "piece"}},
+		{Quantity{4.4, "pcs"}, Quantity{4, "piece"}},
+		{Quantity{2, "bucket"}, Quantity{2, "bucket"}},
+		{Quantity{0.004, "kg"}, Quantity{0.004, "kg"}},
+		{Quantity{0.2504, "l"}, Quantity{0.25, "l"}},
+		{Quantity{1.234, "kg"}, Quantity{1.23, "kg"}},
+	}
+	for _, testcase := range testData {
+		rounded := testcase.quantity.Round()
+		if rounded.Unit != testcase.rounded.Unit || math.Abs(rounded.Amount-testcase.rounded.Amount) > 1e-9 {
+			t.Errorf("%v is rounded to %v, not %v", testcase.quantity, rounded, testcase.rounded)
+		}
+	}
+}
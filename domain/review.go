@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"math"
+)
+
+// Review is a score and an opinion of a user about a food
+type Review struct {
+	gorm.Model
+	FoodID   uint   `gorm:"uniqueIndex:idx_review"`
+	AuthorID uint   `gorm:"uniqueIndex:idx_review"`
+	Author   User   `gorm:"foreignKey:AuthorID" validate:"-"`
+	Score    uint   // from 1 to 5
+	Text     string `gorm:"default:''"`
+}
+
+var InvalidScoreError = errors.New("score must be from 1 to 5")
+
+// CheckScore checks the score is from 1 to MaxRating
+func (r *Review) CheckScore() error {
+	if r.Score < 1 || r.Score > MaxRating {
+		return InvalidScoreError
+	}
+	return nil
+}
+
+// WrittenBy reports whether the user is the author of the review
+func (r *Review) WrittenBy(user *User) bool {
+	return user != nil && r.AuthorID == user.ID
+}
+
+// Rating is an aggregate of review scores of a food
+type Rating struct {
+	// Average is the average score, 0 if there are no reviews
+	Average float64
+	Count   int64
+	// Distribution is counts of reviews by scores from 1 to 5
+	Distribution [MaxRating]int64
+}
+
+// NewRating makes a rating by counts of reviews by scores, unknown scores are skipped
+func NewRating(counts map[uint]int64) Rating {
+	var rating Rating
+	var sum int64
+	for score, count := range counts {
+		if score < 1 || score > MaxRating {
+			continue
+		}
+		rating.Distribution[score-1] = count
+		rating.Count += count
+		sum += int64(score) * count
+	}
+	if rating.Count > 0 {
+		// round to hundredths
+		rating.Average = math.Round(float64(sum)/float64(rating.Count)*100) / 100
+	}
+	return rating
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "testing"
+
+func TestNewRating(t *testing.T) {
+	rating := NewRating(map[uint]int64{5: 2, 4: 1, 1: 3, 7: 1})
+	if rating.Count != 6 || rating.Average != 2.83 {
+		t.Errorf("rating is %f of %d reviews, not 2.83 of 6", rating.Average, rating.Count)
+	}
+	if rating.Distribution != [MaxRating]int64{3, 0, 0, 1, 2} {
+		t.Error("wrong distribution ", rating.Distribution)
+	}
+	if empty := NewRating(nil); empty.Count != 0 || empty.Average != 0 {
+		t.Error("empty rating is not zero")
+	}
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+)
+
+// Scorer rates a food recommendation, the higher score is the better recommendation
+type Scorer interface {
+	Score(foodRecommendation FoodRecommendation) float64
+}
+
+type ScorerFunc func(foodRecommendation FoodRecommendation) float64
+
+func (f ScorerFunc) Score(foodRecommendation FoodRecommendation) float64 {
+	return f(foodRecommendation)
+}
+
+// CoverageScorer prefers foods which ingredients are covered the most
+var CoverageScorer Scorer = ScorerFunc(func(r FoodRecommendation) float64 {
+	return r.Coverage
+})
+
+// FewestMissingScorer prefers foods with the fewest absent ingredients
+var FewestMissingScorer Scorer = ScorerFunc(func(r FoodRecommendation) float64 {
+	return -float64(len(r.AbsentIngredients))
+})
+
+// MissingWeightScorer prefers foods with the least weight of absent ingredients and shortages
+var MissingWeightScorer Scorer = ScorerFunc(func(r FoodRecommendation) float64 {
+	missing := 0.0
+	for _, ingredientWeight := range r.Food.IngredientWeights {
+		for _, ingredient := range r.AbsentIngredients {
+			if ingredient.ID == ingredientWeight.IngredientID {
+				missing += ingredientWeight.Weight
+				break
+			}
+		}
+	}
+	for _, shortage := range r.Shortages {
+		missing += shortage.Shortage
+	}
+	return -missing
+})
+
+// CalorieScorer prefers covered foods with less energy, coverage is halved by every 1000 kcal
+var CalorieScorer Scorer = ScorerFunc(func(r FoodRecommendation) float64 {
+	return r.Coverage / (1 + r.Food.Calories()/1000)
+})
+
+const DefaultScorerName = "coverage"
+
+// Scorers are built-in scorers by name
+var Scorers = map[string]Scorer{
+	DefaultScorerName: CoverageScorer,
+	"missing":         FewestMissingScorer,
+	"missingWeight":   MissingWeightScorer,
+	"calories":        CalorieScorer,
+}
+
+var UnknownScorerError = errors.New("unknown scorer")
+
+// ScorerByName returns a built-in scorer, the default one for empty name
+func ScorerByName(name string) (Scorer, error) {
+	if name == "" {
+		name = DefaultScorerName
+	}
+	scorer, ok := Scorers[name]
+	if !ok {
+		return nil, UnknownScorerError
+	}
+	return scorer, nil
+}
+
+// RankFoodRecommendations scores recommendations and orders them by score boosted by urgency and preference,
+// then by average rating, then by count of available ingredients and then by count of food ingredients
+func RankFoodRecommendations(foodRecommendations []FoodRecommendation, scorer Scorer) {
+	if scorer == nil {
+		scorer = CoverageScorer
+	}
+	for i := range foodRecommendations {
+		foodRecommendations[i].Score = scorer.Score(foodRecommendations[i]) + foodRecommendations[i].Urgency +
+			foodRecommendations[i].Preference
+	}
+	sort.SliceStable(foodRecommendations, func(i, j int) bool {
+		a, b := foodRecommendations[i], foodRecommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Food.Rating.Average != b.Food.Rating.Average {
+			return a.Food.Rating.Average > b.Food.Rating.Average
+		}
+		if len(a.HasIngredients) != len(b.HasIngredients) {
+			return len(a.HasIngredients) > len(b.HasIngredients)
+		}
+		return len(a.Food.IngredientWeights) < len(b.Food.IngredientWeights)
+	})
+}
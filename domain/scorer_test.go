@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"testing"
+)
+
+func testRecommendations() []FoodRecommendation {
+	bacon := Ingredient{Name: "bacon", Nutrients: Nutrients{Calories: 500}}
+	bacon.ID = 1
+	pasta := Ingredient{Name: "pasta", Nutrients: Nutrients{Calories: 350}}
+	pasta.ID = 2
+	egg := Ingredient{Name: "egg", Nutrients: Nutrients{Calories: 150}}
+	egg.ID = 3
+	carbonara := Food{Name: "carbonara", IngredientWeights: []IngredientWeight{
+		{IngredientID: bacon.ID, Ingredient: bacon, Weight: 0.5},
+		{IngredientID: pasta.ID, Ingredient: pasta, Weight: 0.5},
+		{IngredientID: egg.ID, Ingredient: egg, Weight: 0.1},
+	}}
+	omelet := Food{Name: "omelet", IngredientWeights: []IngredientWeight{
+		{IngredientID: bacon.ID, Ingredient: bacon, Weight: 0.05},
+		{IngredientID: egg.ID, Ingredient: egg, Weight: 0.3},
+	}}
+	stock := Stock{Items: []StockItem{
+		{Ingredient: bacon, Weight: 0.2},
+		{Ingredient: pasta},
+	}}
+	return []FoodRecommendation{
+		FoodToFoodRecommendation(carbonara, stock),
+		FoodToFoodRecommendation(omelet, stock),
+	}
+}
+
+func TestScorerByName(t *testing.T) {
+	scorer, err := ScorerByName("")
+	if err != nil || scorer == nil {
+		t.Error("default scorer is not returned")
+	}
+	_, err = ScorerByName("unknown")
+	if err != UnknownScorerError {
+		t.Error("err is not equal error ", UnknownScorerError)
+	}
+}
+
+func TestRankFoodRecommendations(t *testing.T) {
+	var testData = []struct {
+		scorer Scorer
+		first  string
+	}{
+		// carbonara covers (0.4 + 1 + 0) / 3, omelet covers (1 + 0) / 2
+		{CoverageScorer, "omelet"},
+		// both miss egg
+		{FewestMissingScorer, "carbonara"},
+		// carbonara misses 0.3 kg of bacon and 0.1 kg of egg, omelet misses 0.3 kg of egg
+		{MissingWeightScorer, "omelet"},
+		// carbonara has 4400 kcal, omelet has 700 kcal
+		{CalorieScorer, "omelet"},
+	}
+	for _, testcase := range testData {
+		foodRecommendations := testRecommendations()
+		RankFoodRecommendations(foodRecommendations, testcase.scorer)
+		if foodRecommendations[0].Food.Name != testcase.first {
+			t.Errorf("%s is not first", testcase.first)
+		}
+		if foodRecommendations[0].Score < foodRecommendations[1].Score {
+			t.Error("score is not ordered")
+		}
+	}
+}
+
+func TestRankFoodRecommendations_Urgency(t *testing.T) {
+	foodRecommendations := testRecommendations()
+	foodRecommendations[0].Urgency = 1
+	RankFoodRecommendations(foodRecommendations, CoverageScorer)
+	if foodRecommendations[0].Food.Name != "carbonara" {
+		t.Error("urgency is not boosted")
+	}
+}
+
+func TestRankFoodRecommendations_Rating(t *testing.T) {
+	foodRecommendations := []FoodRecommendation{
+		{Food: Food{Name: "plain"}, Coverage: 1},
+		{Food: Food{Name: "rated", Rating: Rating{Average: 4.5, Count: 2}}, Coverage: 1},
+		{Food: Food{Name: "covered"}, Coverage: 0.5},
+	}
+	RankFoodRecommendations(foodRecommendations, CoverageScorer)
+	for i, name := range []string{"rated", "plain", "covered"} {
+		if foodRecommendations[i].Food.Name != name {
+			t.Errorf("%s is not %d", name, i)
+		}
+	}
+}
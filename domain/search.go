@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// IngredientMatch is an ingredient found by a search query
+type IngredientMatch struct {
+	Ingredient Ingredient
+	// MatchedName is the ingredient name or alias matched the query
+	MatchedName string
+	// Score is from 0 to 1, exact match is 1
+	Score float64
+}
+
+// SearchIngredients ranks ingredients matched the query by names and aliases
+func SearchIngredients(ingredients []Ingredient, query string, limit int) []IngredientMatch {
+	query = SearchTerm(query)
+	matches := make([]IngredientMatch, 0)
+	if query == "" {
+		return matches
+	}
+	for _, ingredient := range ingredients {
+		best := IngredientMatch{Ingredient: ingredient}
+		names := []string{ingredient.Name}
+		for _, alias := range ingredient.Aliases {
+			names = append(names, alias.Name)
+		}
+		for _, name := range names {
+			if score := matchScore(query, name); score > best.Score {
+				best.Score = score
+				best.MatchedName = name
+			}
+		}
+		if best.Score > 0 {
+			matches = append(matches, best)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].MatchedName < matches[j].MatchedName
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// SearchTerm returns the lower case query with single spaces, names of substring candidates contain it
+func SearchTerm(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// SearchPrefix returns the first half of the search term, names of candidates start with it
+// or have a word which starts with it, so long names with typos in the rest of the query are found
+func SearchPrefix(query string) string {
+	runes := []rune(SearchTerm(query))
+	return string(runes[:(len(runes)+1)/2])
+}
+
+// SearchLengths returns the range of name lengths which can match the query with typos anywhere,
+// both are 0 if the query is too short for typos
+func SearchLengths(query string) (int, int) {
+	length := len([]rune(SearchTerm(query)))
+	typos := maxTypos(length)
+	if typos == 0 {
+		return 0, 0
+	}
+	return length - typos, length + typos
+}
+
+// maxTypos is the edit distance allowed for a query of the length
+func maxTypos(length int) int {
+	return length / 3
+}
+
+// matchScore scores the name by the lower case query:
+// exact match, prefix, word prefix, substring and then edit distance,
+// shorter names are preferred within the same kind of match
+func matchScore(query string, name string) float64 {
+	name = SearchTerm(name)
+	if name == "" {
+		return 0
+	}
+	lengthRatio := float64(len([]rune(query))) / float64(len([]rune(name)))
+	switch {
+	case name == query || NormalizeIngredientName(name) == NormalizeIngredientName(query):
+		return 1
+	case strings.HasPrefix(name, query):
+		return 0.8 + 0.1*lengthRatio
+	case strings.Contains(" "+name, " "+query):
+		return 0.6 + 0.1*lengthRatio
+	case strings.Contains(name, query):
+		return 0.4 + 0.1*lengthRatio
+	}
+	// typos are compared with the name prefix of the query length
+	queryRunes, nameRunes := []rune(query), []rune(name)
+	if len(nameRunes) > len(queryRunes) {
+		nameRunes = nameRunes[:len(queryRunes)]
+	}
+	distance := levenshtein(queryRunes, nameRunes)
+	maxDistance := maxTypos(len(queryRunes))
+	if distance == 0 || distance > maxDistance {
+		return 0
+	}
+	return 0.3 * (1 - float64(distance)/float64(len(queryRunes)))
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestSearchIngredients(t *testing.T) {
+	ingredients := []Ingredient{
+		{Name: "smoked bacon"},
+		{Name: "bacon"},
+		{Name: "baking powder"},
+		{Name: "chicken", Aliases: []IngredientAlias{{Name: "hen"}}},
+		{Name: "tobacco leaves"},
+	}
+	var testData = []struct {
+		query string
+		names []string
+	}{
+		// prefix is better than word prefix, word prefix is better than substring,
+		// substring is better than typo
+		{"bac", []string{"bacon", "smoked bacon", "tobacco leaves", "baking powder"}},
+		// exact match is the first
+		{"Bacon", []string{"bacon", "smoked bacon"}},
+		// typo
+		{"chiken", []string{"chicken"}},
+		// alias
+		{"hen", []string{"hen"}},
+		{"unknown", []string{}},
+	}
+	for _, testcase := range testData {
+		matches := SearchIngredients(ingredients, testcase.query, 10)
+		if len(matches) != len(testcase.names) {
+			t.Errorf("\"%s\" matches %d ingredients, not %d", testcase.query, len(matches), len(testcase.names))
+			continue
+		}
+		for i, name := range testcase.names {
+			if matches[i].MatchedName != name {
+				t.Errorf("\"%s\" match %d is \"%s\", not \"%s\"", testcase.query, i, matches[i].MatchedName, name)
+			}
+		}
+	}
+	// check limit
+	if len(SearchIngredients(ingredients, "bac", 1)) != 1 {
+		t.Error("limit is not applied")
+	}
+}
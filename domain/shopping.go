@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"sort"
+)
+
+// DefaultAisle groups ingredients which have no store aisle
+const DefaultAisle = "other"
+
+// ShoppingFood is a food to cook, the number of servings of the food if servings are zero
+type ShoppingFood struct {
+	FoodID   uint
+	Servings uint
+}
+
+type ShoppingListQuery struct {
+	Foods []ShoppingFood
+	// Have are available ingredients, an ingredient with an unknown quantity is not bought
+	Have []IngredientQuantity
+	// ViewerID is the user who can see the foods
+	ViewerID uint `json:"-"`
+}
+
+func (q ShoppingListQuery) HaveNames() []string {
+	names := make([]string, len(q.Have))
+	for i, ingredient := range q.Have {
+		names[i] = ingredient.Name
+	}
+	return names
+}
+
+// Stock makes a stock from available quantities of resolved ingredients,
+// resolved is a map of ingredients by normalized name
+func (q ShoppingListQuery) Stock(resolved map[string]Ingredient) (Stock, []string) {
+	return FoodsByIngredientsQuery{Ingredients: q.Have}.Stock(resolved)
+}
+
+// ShoppingItem is an ingredient to buy for all foods
+type ShoppingItem struct {
+	Ingredient Ingredient
+	Needed     float64 //kg for all foods, 0 if the quantity is unknown
+	Available  float64 //kg
+	Weight     float64 //kg to buy, 0 if the quantity is unknown
+	// Quantity is the weight to buy in the unit of food ingredient weights, kg if the units differ,
+	// nil if the quantity is unknown
+	Quantity *Quantity
+	// FoodIDs are foods which need the ingredient
+	FoodIDs []uint
+	unit    string
+}
+
+func (si *ShoppingItem) addFood(foodID uint) {
+	for _, id := range si.FoodIDs {
+		if id == foodID {
+			return
+		}
+	}
+	si.FoodIDs = append(si.FoodIDs, foodID)
+}
+
+// ShoppingAisle is a group of items in a store aisle
+type ShoppingAisle struct {
+	Aisle string
+	Items []ShoppingItem
+}
+
+type ShoppingList struct {
+	Aisles                []ShoppingAisle
+	UnresolvedIngredients []string
+}
+
+// NewShoppingList merges ingredients of foods, subtracts available ingredients of the stock
+// and groups items by aisles, aisles and items are sorted by name,
+// an ingredient is not bought if it is available in an unknown or sufficient quantity
+func NewShoppingList(foods []Food, stock Stock) ShoppingList {
+	items := make([]ShoppingItem, 0)
+	itemIndexes := make(map[uint]int)
+	for _, food := range foods {
+		for _, ingredientWeight := range food.IngredientWeights {
+			i, ok := itemIndexes[ingredientWeight.IngredientID]
+			if !ok {
+				i = len(items)
+				itemIndexes[ingredientWeight.IngredientID] = i
+				items = append(items, ShoppingItem{Ingredient: ingredientWeight.Ingredient, unit: ingredientWeight.Unit})
+			}
+			item := &items[i]
+			item.Needed += ingredientWeight.Weight
+			if item.unit != ingredientWeight.Unit {
+				item.unit = CanonicalUnit
+			}
+			item.addFood(food.ID)
+		}
+	}
+	aisleIndexes := make(map[string]int)
+	list := ShoppingList{Aisles: make([]ShoppingAisle, 0), UnresolvedIngredients: make([]string, 0)}
+	for _, item := range items {
+		if stockItem, ok := stock.find(item.Ingredient.ID); ok {
+			if stockItem.Weight == 0 || stockItem.Weight >= item.Needed {
+				continue
+			}
+			item.Available = stockItem.Weight
+		}
+		item.Weight = item.Needed - item.Available
+		if item.Weight > 0 {
+			unit := item.unit
+			if unit == "" {
+				unit = CanonicalUnit
+			}
+			if quantity, err := QuantityOf(item.Weight, unit, item.Ingredient); err == nil {
+				quantity = quantity.Round()
+				item.Quantity = &quantity
+			}
+		}
+		aisle := item.Ingredient.Aisle
+		if aisle == "" {
+			aisle = DefaultAisle
+		}
+		i, ok := aisleIndexes[aisle]
+		if !ok {
+			i = len(list.Aisles)
+			aisleIndexes[aisle] = i
+			list.Aisles = append(list.Aisles, ShoppingAisle{Aisle: aisle})
+		}
+		list.Aisles[i].Items = append(list.Aisles[i].Items, item)
+	}
+	sort.Slice(list.Aisles, func(i, j int) bool {
+		return list.Aisles[i].Aisle < list.Aisles[j].Aisle
+	})
+	for _, aisle := range list.Aisles {
+		items := aisle.Items
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Ingredient.Name < items[j].Ingredient.Name
+		})
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"math"
+	"testing"
+)
+
+func TestNewShoppingList(t *testing.T) {
+	egg := Ingredient{Model: gorm.Model{ID: 1}, Name: "egg", PieceWeight: 50, Aisle: "dairy"}
+	milk := Ingredient{Model: gorm.Model{ID: 2}, Name: "milk", Aisle: "dairy"}
+	flour := Ingredient{Model: gorm.Model{ID: 3}, Name: "flour"}
+	salt := Ingredient{Model: gorm.Model{ID: 4}, Name: "salt"}
+	foods := []Food{
+		{Model: gorm.Model{ID: 10}, IngredientWeights: []IngredientWeight{
+			{IngredientID: egg.ID, Ingredient: egg, Weight: 0.1, Unit: "piece"},
+			{IngredientID: flour.ID, Ingredient: flour, Weight: 0.2},
+			{IngredientID: salt.ID, Ingredient: salt},
+		}},
+		{Model: gorm.Model{ID: 11}, IngredientWeights: []IngredientWeight{
+			{IngredientID: egg.ID, Ingredient: egg, Weight: 0.15, Unit: "piece"},
+			{IngredientID: milk.ID, Ingredient: milk, Weight: 0.5},
+		}},
+	}
+	stock := Stock{Items: []StockItem{
+		{Ingredient: egg, Weight: 0.05},
+		{Ingredient: milk},
+		{Ingredient: flour, Weight: 0.3},
+	}}
+	list := NewShoppingList(foods, stock)
+	if len(list.Aisles) != 2 || list.Aisles[0].Aisle != "dairy" || list.Aisles[1].Aisle != DefaultAisle {
+		t.Fatal("wrong aisles ", list.Aisles)
+	}
+	// check merged ingredient without available milk and enough flour
+	dairy := list.Aisles[0].Items
+	if len(dairy) != 1 || dairy[0].Ingredient.ID != egg.ID || len(dairy[0].FoodIDs) != 2 {
+		t.Fatal("wrong dairy items ", dairy)
+	}
+	if math.Abs(dairy[0].Needed-0.25) > 1e-9 || math.Abs(dairy[0].Weight-0.2) > 1e-9 {
+		t.Error("wrong egg weight ", dairy[0].Weight)
+	}
+	if quantity := dairy[0].Quantity; quantity == nil || quantity.Unit != "piece" || math.Abs(quantity.Amount-4) > 1e-9 {
+		t.Error("egg quantity is not in pieces ", quantity)
+	}
+	// check unknown weight
+	other := list.Aisles[1].Items
+	if len(other) != 1 || other[0].Ingredient.ID != salt.ID || other[0].Weight != 0 || other[0].Quantity != nil {
+		t.Error("wrong other items ", other)
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// Step is a cooking step of a food, steps of a food are ordered by positions from 1
+type Step struct {
+	gorm.Model
+	FoodID      uint
+	Position    uint
+	Text        string       `validate:"nonzero"`
+	Duration    uint         // minutes
+	Ingredients []Ingredient `gorm:"many2many:step_ingredients" validate:"-"`
+	Equipment   StringList
+}
+
+// StringList is a list of strings stored as a json array
+type StringList []string
+
+func (l StringList) GormDataType() string {
+	return "text"
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal(l)
+	return string(value), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	}
+	return errors.New("string list is not a string")
+}
+
+// StepIngredientError is returned when a step refers to an ingredient which is not in the food
+var StepIngredientError = errors.New("step ingredient is not an ingredient of the food")
+
+// InvalidStepOrderError is returned when a new order does not list every step of the food once
+var InvalidStepOrderError = errors.New("step order must list every step of the food once")
+
+// ResolveStepIngredients replaces step ingredient references by ingredients of the food
+func (f *Food) ResolveStepIngredients(step *Step) error {
+	for i, reference := range step.Ingredients {
+		found := false
+		for _, ingredientWeight := range f.IngredientWeights {
+			if ingredientWeight.IngredientID == reference.ID {
+				step.Ingredients[i] = ingredientWeight.Ingredient
+				step.Ingredients[i].ID = reference.ID
+				found = true
+				break
+			}
+		}
+		if !found {
+			return StepIngredientError
+		}
+	}
+	return nil
+}
+
+// CheckStepOrder checks that step ids are a permutation of the steps
+func CheckStepOrder(steps []Step, stepIDs []uint) error {
+	if len(steps) != len(stepIDs) {
+		return InvalidStepOrderError
+	}
+	positions := make(map[uint]bool, len(steps))
+	for _, step := range steps {
+		positions[step.ID] = false
+	}
+	for _, id := range stepIDs {
+		listed, ok := positions[id]
+		if !ok || listed {
+			return InvalidStepOrderError
+		}
+		positions[id] = true
+	}
+	return nil
+}
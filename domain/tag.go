@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+)
+
+type TagKind string
+
+const (
+	CuisineTag TagKind = "cuisine"
+	MealTag    TagKind = "meal"
+	CourseTag  TagKind = "course"
+	FreeTag    TagKind = "free"
+)
+
+var TagKinds = []TagKind{CuisineTag, MealTag, CourseTag, FreeTag}
+
+var InvalidTagKindError = errors.New("tag kind must be cuisine, meal, course or free")
+
+// Tag categorises foods, a tag without a kind is a free tag
+type Tag struct {
+	gorm.Model
+	Name string  `validate:"nonzero"`
+	Kind TagKind `gorm:"index"`
+}
+
+// CheckKind sets the free kind if it is empty and checks the kind is known
+func (t *Tag) CheckKind() error {
+	if t.Kind == "" {
+		t.Kind = FreeTag
+	}
+	for _, kind := range TagKinds {
+		if t.Kind == kind {
+			return nil
+		}
+	}
+	return InvalidTagKindError
+}
+
+func (t *Tag) Equal(tag interface{}) bool {
+	t2, ok := tag.(*Tag)
+	if !ok {
+		return false
+	}
+	if t2 == t || t2.ID == t.ID {
+		return true
+	}
+	return t2.Name == t.Name && t2.Kind == t.Kind
+}
+
+// TagFilter selects foods by tag ids, empty lists do not filter
+type TagFilter struct {
+	// AnyTags selects foods which have at least one of the tags
+	AnyTags []uint
+	// AllTags selects foods which have every tag
+	AllTags []uint
+	// ExcludeTags skips foods which have one of the tags
+	ExcludeTags []uint
+}
+
+// TagFacet is a count of filtered foods with the tag
+type TagFacet struct {
+	Tag   Tag
+	Count int64
+}
+
+// NewTagFacets makes facets of tags by food counts, facets are grouped by kinds and sorted by counts
+func NewTagFacets(tags []Tag, counts map[uint]int64) []TagFacet {
+	facets := make([]TagFacet, 0, len(tags))
+	for _, tag := range tags {
+		if counts[tag.ID] > 0 {
+			facets = append(facets, TagFacet{tag, counts[tag.ID]})
+		}
+	}
+	sort.SliceStable(facets, func(i, j int) bool {
+		if facets[i].Tag.Kind != facets[j].Tag.Kind {
+			return facets[i].Tag.Kind < facets[j].Tag.Kind
+		}
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Tag.Name < facets[j].Tag.Name
+	})
+	return facets
+}
+
+// TagFacetsOf counts tags of loaded foods
+func TagFacetsOf(foods []Food) []TagFacet {
+	counts := make(map[uint]int64)
+	tags := make([]Tag, 0)
+	for _, food := range foods {
+		for _, tag := range food.Tags {
+			if counts[tag.ID] == 0 {
+				tags = append(tags, tag)
+			}
+			counts[tag.ID]++
+		}
+	}
+	return NewTagFacets(tags, counts)
+}
+
+type TagRepository interface {
+	CrudRepository
+	// List returns tags of the kind, all tags if the kind is empty
+	List(kind TagKind) ([]Tag, error)
+}
+
+type TagService interface {
+	Save(tag *Tag) error
+	Update(id uint, tag *Tag) error
+	Delete(id uint) error
+	Get(id uint) (*Tag, error)
+	List(kind TagKind) ([]Tag, error)
+}
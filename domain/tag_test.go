@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestTagFacetsOf(t *testing.T) {
+	italian := Tag{Model: gorm.Model{ID: 1}, Name: "italian", Kind: CuisineTag}
+	mexican := Tag{Model: gorm.Model{ID: 2}, Name: "mexican", Kind: CuisineTag}
+	breakfast := Tag{Model: gorm.Model{ID: 3}, Name: "breakfast", Kind: MealTag}
+	foods := []Food{
+		{Tags: []Tag{mexican, breakfast}},
+		{Tags: []Tag{italian}},
+		{Tags: []Tag{italian, breakfast}},
+	}
+	facets := TagFacetsOf(foods)
+	expected := []TagFacet{{italian, 2}, {mexican, 1}, {breakfast, 2}}
+	if len(facets) != len(expected) {
+		t.Fatal("wrong facets count")
+	}
+	for i, facet := range facets {
+		if facet.Tag.ID != expected[i].Tag.ID || facet.Count != expected[i].Count {
+			t.Errorf("facet %d is %s (%d), not %s (%d)", i,
+				facet.Tag.Name, facet.Count, expected[i].Tag.Name, expected[i].Count)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type User struct {
+	gorm.Model
+	Name         string `gorm:"uniqueIndex" validate:"nonzero"`
+	PasswordHash string `json:"-"`
+	Role         Role   `gorm:"default:viewer"`
+}
+
+// Role is a set of permissions of a user, every role has permissions of lower roles
+type Role string
+
+const (
+	// ViewerRole can manage own foods and pantries
+	ViewerRole Role = "viewer"
+	// EditorRole can change the ingredient catalogue
+	EditorRole Role = "editor"
+	// AdminRole can change any food and user roles
+	AdminRole Role = "admin"
+)
+
+var Roles = []Role{ViewerRole, EditorRole, AdminRole}
+
+// rank is an order of the role, 0 if the role is unknown
+func (r Role) rank() int {
+	for i, role := range Roles {
+		if r == role {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// HasRole reports whether the user has the role or a higher one
+func (u *User) HasRole(role Role) bool {
+	return u.Role.rank() >= role.rank() && role.rank() > 0
+}
+
+// CanEdit reports whether the user can update or delete the food, only owners and admins can
+func (u *User) CanEdit(f *Food) bool {
+	return u.HasRole(AdminRole) || (f.OwnerID != 0 && f.OwnerID == u.ID)
+}
+
+func (u *User) Equal(user interface{}) bool {
+	u2, ok := user.(*User)
+	if !ok {
+		return false
+	}
+	if u2 == u || u2.ID == u.ID {
+		return true
+	}
+	return u2.Name == u.Name
+}
+
+// Scope is an access level of a token
+type Scope string
+
+const (
+	ReadScope  Scope = "read"
+	WriteScope Scope = "write"
+)
+
+// Allows reports whether the scope allows the required access, the write scope allows reads
+func (s Scope) Allows(required Scope) bool {
+	return s == required || s == WriteScope
+}
+
+// Token is an opaque bearer token of a user, only a hash of the token is stored,
+// login tokens expire and API keys are named and do not expire
+type Token struct {
+	gorm.Model
+	UserID    uint
+	User      User       `validate:"-" json:"-"`
+	Name      string     `gorm:"default:''"` // API key name, empty for login tokens
+	Hash      string     `gorm:"uniqueIndex" json:"-"`
+	Scope     Scope      `gorm:"default:write"`
+	ExpiresAt *time.Time // nil for API keys
+}
+
+// IsAPIKey reports whether the token is an API key
+func (t *Token) IsAPIKey() bool {
+	return t.ExpiresAt == nil
+}
+
+const (
+	MinPasswordLength = 8
+	TokenLifetime     = 30 * 24 * time.Hour
+)
+
+var (
+	UserExistsError         = errors.New("user already exists")
+	InvalidPasswordError    = errors.New("password must have at least 8 characters")
+	InvalidUserNameError    = errors.New("user name must not be empty")
+	InvalidCredentialsError = errors.New("invalid user name or password")
+	InvalidTokenError       = errors.New("invalid or expired token")
+	UnauthenticatedError    = errors.New("authentication required")
+	InvalidScopeError       = errors.New("scope must be read or write")
+	InsufficientScopeError  = errors.New("token scope does not allow the request")
+	InvalidRoleError        = errors.New("role must be viewer, editor or admin")
+	ForbiddenError          = errors.New("user role does not allow the request")
+)
+
+type viewerKey struct{}
+
+type scopeKey struct{}
+
+// ContextWithViewer returns a context of a request made by the user
+func ContextWithViewer(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, viewerKey{}, user)
+}
+
+// ViewerFromContext returns the user who made the request, nil if the request is anonymous
+func ViewerFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(viewerKey{}).(*User)
+	return user
+}
+
+// ContextWithScope returns a context of a request authenticated with a token of the scope
+func ContextWithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope of the request token, empty if the request is anonymous
+func ScopeFromContext(ctx context.Context) Scope {
+	scope, _ := ctx.Value(scopeKey{}).(Scope)
+	return scope
+}
+
+// ViewerID returns the id of the user who made the request, 0 if the request is anonymous
+func ViewerID(ctx context.Context) uint {
+	if user := ViewerFromContext(ctx); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// RequireRole checks the user who made the request has the role
+func RequireRole(ctx context.Context, role Role) error {
+	user := ViewerFromContext(ctx)
+	if user == nil {
+		return UnauthenticatedError
+	}
+	if !user.HasRole(role) {
+		return ForbiddenError
+	}
+	return nil
+}
+
+type UserRepository interface {
+	CrudRepository
+	FindByName(name string) (*User, error)
+	SaveToken(token *Token) error
+	// FindToken returns an unexpired token with its user by the hash
+	FindToken(hash string, now time.Time) (*Token, error)
+	GetToken(id uint) (*Token, error)
+	DeleteToken(id uint) error
+	APIKeys(userID uint) ([]Token, error)
+}
+
+type UserService interface {
+	Get(id uint) (*User, error)
+	Register(name string, password string) (*User, error)
+	// Login returns a new bearer token of the user
+	Login(name string, password string) (*Token, string, error)
+	// Authenticate returns a token with its user by the bearer token
+	Authenticate(token string) (*Token, error)
+	// CreateAPIKey returns a new API key of the user
+	CreateAPIKey(userID uint, name string, scope Scope) (*Token, string, error)
+	APIKeys(userID uint) ([]Token, error)
+	DeleteAPIKey(userID uint, id uint) error
+	SetRole(id uint, role Role) error
+}
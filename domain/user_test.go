@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestUser_CanEdit(t *testing.T) {
+	viewer := User{Model: gorm.Model{ID: 1}, Role: ViewerRole}
+	editor := User{Model: gorm.Model{ID: 2}, Role: EditorRole}
+	admin := User{Model: gorm.Model{ID: 3}, Role: AdminRole}
+	if !editor.HasRole(ViewerRole) || !editor.HasRole(EditorRole) || editor.HasRole(AdminRole) {
+		t.Error("editor has wrong roles")
+	}
+	if (&User{Role: "owner"}).HasRole(ViewerRole) {
+		t.Error("unknown role has permissions")
+	}
+	own := Food{OwnerID: viewer.ID}
+	shared := Food{}
+	tests := []struct {
+		user    User
+		food    Food
+		canEdit bool
+	}{
+		{viewer, own, true},
+		{viewer, shared, false},
+		{editor, own, false},
+		{editor, shared, false},
+		{admin, own, true},
+		{admin, shared, true},
+	}
+	for i, test := range tests {
+		if test.user.CanEdit(&test.food) != test.canEdit {
+			t.Errorf("case %d: CanEdit is not %v", i, test.canEdit)
+		}
+	}
+}
@@ -8,8 +8,53 @@ import (
 	"what_cook/domain"
 )
 
+// visibleFood returns the food only if the caller can see it
+func visibleFood(ctx context.Context, foodService domain.FoodService, id uint) (*domain.Food, error) {
+	food, err := foodService.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !food.VisibleTo(domain.ViewerID(ctx)) {
+		return nil, domain.ModelNotFoundError
+	}
+	return food, nil
+}
+
+// editableFood returns the food only if the caller can update or delete it,
+// admins can edit foods which are private to other users
+func editableFood(ctx context.Context, foodService domain.FoodService, id uint) (*domain.Food, error) {
+	food, err := foodService.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	user := domain.ViewerFromContext(ctx)
+	if user == nil {
+		if !food.VisibleTo(0) {
+			return nil, domain.ModelNotFoundError
+		}
+		return nil, domain.UnauthenticatedError
+	}
+	if !user.CanEdit(food) {
+		if !food.VisibleTo(user.ID) {
+			return nil, domain.ModelNotFoundError
+		}
+		return nil, domain.ForbiddenError
+	}
+	return food, nil
+}
+
+// checkNewIngredients lets only editors add ingredients to the catalogue with a food
+func checkNewIngredients(ctx context.Context, food *domain.Food) error {
+	if !food.HasNewIngredients() {
+		return nil
+	}
+	return domain.RequireRole(ctx, domain.EditorRole)
+}
+
 type foodRequest struct {
-	ID uint
+	ID       uint
+	Unit     string
+	Servings uint
 }
 
 type foodResponse struct {
@@ -21,10 +66,20 @@ func (f foodResponse) error() error {
 	return f.Err
 }
 
+func (f foodResponse) exported() domain.Exportable {
+	return f.Food
+}
+
 func makeFoodEndpoint(foodService domain.FoodService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, error error) {
-		foodId := request.(foodRequest).ID
-		food, err := foodService.Get(foodId)
+		req := request.(foodRequest)
+		food, err := visibleFood(ctx, foodService, req.ID)
+		if err == nil && req.Unit != "" {
+			err = food.ConvertQuantities(req.Unit)
+		}
+		if err == nil && req.Servings != 0 {
+			food.Scale(req.Servings)
+		}
 		return foodResponse{food, err}, error
 	}
 }
@@ -49,6 +104,13 @@ func makeCreateFoodEndpoint(foodService domain.FoodService) endpoint.Endpoint {
 		if error = validator.Validate(req); error != nil {
 			return createFoodResponse{"", error}, err
 		}
+		if error = req.Food.CheckVisibility(); error != nil {
+			return createFoodResponse{"", error}, err
+		}
+		if error = checkNewIngredients(ctx, req.Food); error != nil {
+			return createFoodResponse{"", error}, err
+		}
+		req.Food.OwnerID = domain.ViewerID(ctx)
 		error = foodService.Save(req.Food)
 		if error != nil {
 			return createFoodResponse{"", error}, err
@@ -76,6 +138,19 @@ func makeUpdateFoodEndpoint(foodService domain.FoodService) endpoint.Endpoint {
 		if validateError := validator.Validate(req); validateError != nil {
 			return updateFoodResponse{validateError}, nil
 		}
+		if _, getError := editableFood(ctx, foodService, req.Id); getError != nil {
+			return updateFoodResponse{getError}, nil
+		}
+		if roleError := checkNewIngredients(ctx, req.Food); roleError != nil {
+			return updateFoodResponse{roleError}, nil
+		}
+		if req.Food.Visibility != "" {
+			if visibilityError := req.Food.CheckVisibility(); visibilityError != nil {
+				return updateFoodResponse{visibilityError}, nil
+			}
+		}
+		// the owner is not changed
+		req.Food.OwnerID = 0
 		updateError := foodService.Update(req.Id, req.Food)
 		return updateFoodResponse{updateError}, err
 	}
@@ -96,18 +171,31 @@ func (r deleteFoodResponse) error() error {
 func makeDeleteFoodEndpoint(foodService domain.FoodService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(deleteFoodRequest)
+		if _, getError := editableFood(ctx, foodService, req.Id); getError != nil {
+			return deleteFoodResponse{getError}, nil
+		}
 		deleteError := foodService.Delete(req.Id)
 		return deleteFoodResponse{deleteError}, err
 	}
 }
 
 type foodsByIngredientsRequest struct {
-	Ingredients []string
+	Ingredients      []domain.IngredientQuantity
+	ExpiringDays     uint
+	MaxTotalMinutes  uint
+	ExcludeAllergens domain.Allergens
+	Diet             domain.Diets
+	BoostFavourites  bool
+	RecentDays       uint
+	domain.TagFilter
+	Scorer domain.Scorer `json:"-"`
 }
 
 type foodsByIngredientsResponse struct {
-	Foods []domain.FoodRecommendation
-	Err   error
+	Foods                 []domain.FoodRecommendation
+	UnresolvedIngredients []string
+	Facets                []domain.TagFacet
+	Err                   error
 }
 
 func (f *foodsByIngredientsResponse) error() error {
@@ -117,10 +205,371 @@ func (f *foodsByIngredientsResponse) error() error {
 func makeFoodsByIngredientEndpoint(foodService domain.FoodService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(foodsByIngredientsRequest)
-		foodRecommendations, foodServiceError := foodService.FindByIngredients(req.Ingredients)
+		result, foodServiceError := foodService.FindByIngredients(domain.FoodsByIngredientsQuery{
+			Ingredients:      req.Ingredients,
+			ExpiringDays:     req.ExpiringDays,
+			MaxTotalMinutes:  req.MaxTotalMinutes,
+			TagFilter:        req.TagFilter,
+			ExcludeAllergens: req.ExcludeAllergens,
+			Diet:             req.Diet,
+			BoostFavourites:  req.BoostFavourites,
+			RecentDays:       req.RecentDays,
+			ViewerID:         domain.ViewerID(ctx),
+			Scorer:           req.Scorer,
+		})
 		if foodServiceError != nil {
-			return foodsByIngredientsResponse{nil, foodServiceError}, err
+			return foodsByIngredientsResponse{nil, nil, nil, foodServiceError}, err
+		}
+		return foodsByIngredientsResponse{result.Foods, result.UnresolvedIngredients, result.Facets, nil}, err
+	}
+}
+
+type listFoodsRequest struct {
+	Query domain.FoodListQuery
+}
+
+type listFoodsResponse struct {
+	domain.FoodList
+	Err error `json:"err,omitempty"`
+}
+
+func (l listFoodsResponse) error() error {
+	return l.Err
+}
+
+func makeListFoodsEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listFoodsRequest)
+		req.Query.ViewerID = domain.ViewerID(ctx)
+		list, listError := foodService.List(req.Query)
+		return listFoodsResponse{list, listError}, nil
+	}
+}
+
+type foodNutritionRequest struct {
+	ID              uint
+	ReferenceIntake domain.Nutrients
+}
+
+type foodNutritionResponse struct {
+	Nutrition *domain.NutritionReport
+	Err       error `json:"err,omitempty"`
+}
+
+func (f foodNutritionResponse) error() error {
+	return f.Err
+}
+
+func makeFoodNutritionEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(foodNutritionRequest)
+		if _, getError := visibleFood(ctx, foodService, req.ID); getError != nil {
+			return foodNutritionResponse{nil, getError}, nil
+		}
+		report, nutritionError := foodService.Nutrition(req.ID, req.ReferenceIntake)
+		if nutritionError != nil {
+			return foodNutritionResponse{nil, nutritionError}, nil
+		}
+		return foodNutritionResponse{&report, nil}, nil
+	}
+}
+
+type stepsRequest struct {
+	FoodID uint
+}
+
+type stepsResponse struct {
+	Steps []domain.Step
+	Err   error `json:"err,omitempty"`
+}
+
+func (s stepsResponse) error() error {
+	return s.Err
+}
+
+func makeStepsEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(stepsRequest)
+		if _, getError := visibleFood(ctx, foodService, req.FoodID); getError != nil {
+			return stepsResponse{nil, getError}, nil
+		}
+		steps, stepsError := foodService.Steps(req.FoodID)
+		return stepsResponse{steps, stepsError}, nil
+	}
+}
+
+type createStepRequest struct {
+	FoodID uint
+	Step   domain.Step
+}
+
+type createStepResponse struct {
+	StepID string
+	Err    error `json:"err,omitempty"`
+}
+
+func (c createStepResponse) error() error {
+	return c.Err
+}
+
+func makeCreateStepEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createStepRequest)
+		if validateError := validator.Validate(req); validateError != nil {
+			return createStepResponse{"", validateError}, nil
+		}
+		if _, getError := editableFood(ctx, foodService, req.FoodID); getError != nil {
+			return createStepResponse{"", getError}, nil
+		}
+		saveError := foodService.SaveStep(req.FoodID, &req.Step)
+		return createStepResponse{strconv.Itoa(int(req.Step.ID)), saveError}, nil
+	}
+}
+
+type updateStepRequest struct {
+	FoodID uint
+	ID     uint
+	Step   domain.Step
+}
+
+type updateStepResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (u updateStepResponse) error() error {
+	return u.Err
+}
+
+func makeUpdateStepEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(updateStepRequest)
+		if _, getError := editableFood(ctx, foodService, req.FoodID); getError != nil {
+			return updateStepResponse{getError}, nil
+		}
+		updateError := foodService.UpdateStep(req.FoodID, req.ID, &req.Step)
+		return updateStepResponse{updateError}, nil
+	}
+}
+
+type deleteStepRequest struct {
+	FoodID uint
+	ID     uint
+}
+
+type deleteStepResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deleteStepResponse) error() error {
+	return d.Err
+}
+
+func makeDeleteStepEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteStepRequest)
+		if _, getError := editableFood(ctx, foodService, req.FoodID); getError != nil {
+			return deleteStepResponse{getError}, nil
+		}
+		deleteError := foodService.DeleteStep(req.FoodID, req.ID)
+		return deleteStepResponse{deleteError}, nil
+	}
+}
+
+type reorderStepsRequest struct {
+	FoodID  uint
+	StepIDs []uint
+}
+
+func makeReorderStepsEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(reorderStepsRequest)
+		if _, getError := editableFood(ctx, foodService, req.FoodID); getError != nil {
+			return stepsResponse{nil, getError}, nil
+		}
+		steps, reorderError := foodService.ReorderSteps(req.FoodID, req.StepIDs)
+		return stepsResponse{steps, reorderError}, nil
+	}
+}
+
+type reviewsRequest struct {
+	FoodID uint
+}
+
+type reviewsResponse struct {
+	Reviews []domain.Review
+	Err     error `json:"err,omitempty"`
+}
+
+func (r reviewsResponse) error() error {
+	return r.Err
+}
+
+func makeReviewsEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(reviewsRequest)
+		if _, getError := visibleFood(ctx, foodService, req.FoodID); getError != nil {
+			return reviewsResponse{nil, getError}, nil
+		}
+		reviews, reviewsError := foodService.Reviews(req.FoodID)
+		return reviewsResponse{reviews, reviewsError}, nil
+	}
+}
+
+type createReviewRequest struct {
+	FoodID uint
+	Review domain.Review
+}
+
+type createReviewResponse struct {
+	ReviewID string
+	Err      error `json:"err,omitempty"`
+}
+
+func (c createReviewResponse) error() error {
+	return c.Err
+}
+
+func makeCreateReviewEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createReviewRequest)
+		if domain.ViewerFromContext(ctx) == nil {
+			return createReviewResponse{"", domain.UnauthenticatedError}, nil
+		}
+		if _, getError := visibleFood(ctx, foodService, req.FoodID); getError != nil {
+			return createReviewResponse{"", getError}, nil
+		}
+		req.Review.AuthorID = domain.ViewerID(ctx)
+		saveError := foodService.SaveReview(req.FoodID, &req.Review)
+		return createReviewResponse{strconv.Itoa(int(req.Review.ID)), saveError}, nil
+	}
+}
+
+type updateReviewRequest struct {
+	FoodID uint
+	ID     uint
+	Review domain.Review
+}
+
+type updateReviewResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (u updateReviewResponse) error() error {
+	return u.Err
+}
+
+// makeUpdateReviewEndpoint lets only the author change the review
+func makeUpdateReviewEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(updateReviewRequest)
+		review, getError := visibleReview(ctx, foodService, req.FoodID, req.ID)
+		if getError != nil {
+			return updateReviewResponse{getError}, nil
+		}
+		if !review.WrittenBy(domain.ViewerFromContext(ctx)) {
+			return updateReviewResponse{domain.ForbiddenError}, nil
+		}
+		updateError := foodService.UpdateReview(req.FoodID, req.ID, &req.Review)
+		return updateReviewResponse{updateError}, nil
+	}
+}
+
+type deleteReviewRequest struct {
+	FoodID uint
+	ID     uint
+}
+
+type deleteReviewResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deleteReviewResponse) error() error {
+	return d.Err
+}
+
+// makeDeleteReviewEndpoint lets the author or an admin delete the review
+func makeDeleteReviewEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteReviewRequest)
+		review, getError := visibleReview(ctx, foodService, req.FoodID, req.ID)
+		if getError != nil {
+			return deleteReviewResponse{getError}, nil
+		}
+		if user := domain.ViewerFromContext(ctx); !review.WrittenBy(user) && !user.HasRole(domain.AdminRole) {
+			return deleteReviewResponse{domain.ForbiddenError}, nil
+		}
+		deleteError := foodService.DeleteReview(req.FoodID, req.ID)
+		return deleteReviewResponse{deleteError}, nil
+	}
+}
+
+// visibleReview returns the review of the food only if the caller is authenticated and can see the food
+func visibleReview(ctx context.Context, foodService domain.FoodService, foodID uint, id uint) (*domain.Review, error) {
+	if domain.ViewerFromContext(ctx) == nil {
+		return nil, domain.UnauthenticatedError
+	}
+	if _, err := visibleFood(ctx, foodService, foodID); err != nil {
+		return nil, err
+	}
+	return foodService.GetReview(foodID, id)
+}
+
+type shoppingListRequest struct {
+	Foods []domain.ShoppingFood
+	Have  []domain.IngredientQuantity
+}
+
+type shoppingListResponse struct {
+	List domain.ShoppingList
+	Err  error `json:"err,omitempty"`
+}
+
+func (s shoppingListResponse) error() error {
+	return s.Err
+}
+
+func (s shoppingListResponse) exported() domain.Exportable {
+	return s.List
+}
+
+func makeShoppingListEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(shoppingListRequest)
+		list, listError := foodService.ShoppingList(domain.ShoppingListQuery{
+			Foods:    req.Foods,
+			Have:     req.Have,
+			ViewerID: domain.ViewerID(ctx),
+		})
+		return shoppingListResponse{list, listError}, nil
+	}
+}
+
+type importRequest struct {
+	Rows       []domain.ImportRow
+	Visibility domain.Visibility
+}
+
+type importResponse struct {
+	Report domain.ImportReport
+	Err    error `json:"err,omitempty"`
+}
+
+func (i importResponse) error() error {
+	return i.Err
+}
+
+// makeImportEndpoint lets editors import foods, created foods are owned by the caller
+func makeImportEndpoint(foodService domain.FoodService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(importRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return importResponse{Err: roleError}, nil
 		}
-		return foodsByIngredientsResponse{foodRecommendations, nil}, err
+		report, importError := foodService.Import(domain.FoodImport{
+			Rows:       req.Rows,
+			OwnerID:    domain.ViewerID(ctx),
+			Visibility: req.Visibility,
+		})
+		return importResponse{report, importError}, nil
 	}
 }
@@ -8,11 +8,33 @@ type service struct {
 	repository domain.FoodRepository
 }
 
-func (s service) FindByIngredients(ingredients []string) ([]domain.FoodRecommendation, error) {
-	return s.repository.FindByIngredients(ingredients)
+func (s service) FindByIngredients(query domain.FoodsByIngredientsQuery) (domain.FoodsByIngredientsResult, error) {
+	return s.repository.FindByIngredients(query)
 }
 
+func (s service) List(query domain.FoodListQuery) (domain.FoodList, error) {
+	if err := query.Page.Validate(domain.FoodSortFields...); err != nil {
+		return domain.FoodList{}, err
+	}
+	return s.repository.List(query)
+}
+
+func (s service) Nutrition(id uint, referenceIntake domain.Nutrients) (domain.NutritionReport, error) {
+	food, err := s.Get(id)
+	if err != nil {
+		return domain.NutritionReport{}, err
+	}
+	return food.NutritionReport(referenceIntake), nil
+}
+
+// Save numbers steps of the food by their order
 func (s service) Save(food *domain.Food) error {
+	for i := range food.Steps {
+		if err := food.ResolveStepIngredients(&food.Steps[i]); err != nil {
+			return err
+		}
+		food.Steps[i].Position = uint(i + 1)
+	}
 	return s.repository.Save(food)
 }
 
@@ -32,6 +54,157 @@ func (s service) Get(id uint) (*domain.Food, error) {
 	return food.(*domain.Food), err
 }
 
+func (s service) Steps(foodID uint) ([]domain.Step, error) {
+	// check food
+	if _, err := s.Get(foodID); err != nil {
+		return nil, err
+	}
+	return s.repository.Steps(foodID)
+}
+
+func (s service) SaveStep(foodID uint, step *domain.Step) error {
+	food, err := s.Get(foodID)
+	if err != nil {
+		return err
+	}
+	if err := food.ResolveStepIngredients(step); err != nil {
+		return err
+	}
+	step.FoodID = foodID
+	return s.repository.SaveStep(step)
+}
+
+func (s service) UpdateStep(foodID uint, id uint, step *domain.Step) error {
+	if _, err := s.getStep(foodID, id); err != nil {
+		return err
+	}
+	food, err := s.Get(foodID)
+	if err != nil {
+		return err
+	}
+	if err := food.ResolveStepIngredients(step); err != nil {
+		return err
+	}
+	return s.repository.UpdateStep(id, step)
+}
+
+func (s service) DeleteStep(foodID uint, id uint) error {
+	if _, err := s.getStep(foodID, id); err != nil {
+		return err
+	}
+	return s.repository.DeleteStep(id)
+}
+
+func (s service) ReorderSteps(foodID uint, stepIDs []uint) ([]domain.Step, error) {
+	steps, err := s.Steps(foodID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.CheckStepOrder(steps, stepIDs); err != nil {
+		return nil, err
+	}
+	if err := s.repository.ReorderSteps(foodID, stepIDs); err != nil {
+		return nil, err
+	}
+	return s.repository.Steps(foodID)
+}
+
+// getStep returns the step only if it belongs to the food
+func (s service) getStep(foodID uint, id uint) (*domain.Step, error) {
+	step, err := s.repository.GetStep(id)
+	if err != nil {
+		return nil, err
+	}
+	if step.FoodID != foodID {
+		return nil, domain.ModelNotFoundError
+	}
+	return step, nil
+}
+
+func (s service) Reviews(foodID uint) ([]domain.Review, error) {
+	// check food
+	if _, err := s.Get(foodID); err != nil {
+		return nil, err
+	}
+	return s.repository.Reviews(foodID)
+}
+
+// GetReview returns the review only if it belongs to the food
+func (s service) GetReview(foodID uint, id uint) (*domain.Review, error) {
+	review, err := s.repository.GetReview(id)
+	if err != nil {
+		return nil, err
+	}
+	if review.FoodID != foodID {
+		return nil, domain.ModelNotFoundError
+	}
+	return review, nil
+}
+
+func (s service) SaveReview(foodID uint, review *domain.Review) error {
+	if err := review.CheckScore(); err != nil {
+		return err
+	}
+	// check food
+	if _, err := s.Get(foodID); err != nil {
+		return err
+	}
+	review.FoodID = foodID
+	return s.repository.SaveReview(review)
+}
+
+// UpdateReview replaces the score and the text of the review
+func (s service) UpdateReview(foodID uint, id uint, review *domain.Review) error {
+	if err := review.CheckScore(); err != nil {
+		return err
+	}
+	if _, err := s.GetReview(foodID, id); err != nil {
+		return err
+	}
+	return s.repository.UpdateReview(id, review)
+}
+
+func (s service) DeleteReview(foodID uint, id uint) error {
+	if _, err := s.GetReview(foodID, id); err != nil {
+		return err
+	}
+	return s.repository.DeleteReview(id)
+}
+
+// ShoppingList sums ingredients of the foods scaled to servings, foods must be visible to the viewer
+func (s service) ShoppingList(query domain.ShoppingListQuery) (domain.ShoppingList, error) {
+	foods := make([]domain.Food, 0, len(query.Foods))
+	for _, shoppingFood := range query.Foods {
+		food, err := s.Get(shoppingFood.FoodID)
+		if err != nil {
+			return domain.ShoppingList{}, err
+		}
+		if !food.VisibleTo(query.ViewerID) {
+			return domain.ShoppingList{}, domain.ModelNotFoundError
+		}
+		food.Scale(shoppingFood.Servings)
+		foods = append(foods, *food)
+	}
+	resolved, err := s.repository.ResolveIngredients(query.HaveNames())
+	if err != nil {
+		return domain.ShoppingList{}, err
+	}
+	stock, unresolved := query.Stock(resolved)
+	list := domain.NewShoppingList(foods, stock)
+	list.UnresolvedIngredients = unresolved
+	return list, nil
+}
+
+// Import checks the visibility of created foods, it is private if it is not set
+func (s service) Import(foodImport domain.FoodImport) (domain.ImportReport, error) {
+	food := domain.Food{Visibility: foodImport.Visibility}
+	if err := food.CheckVisibility(); err != nil {
+		return domain.ImportReport{}, err
+	}
+	foodImport.Visibility = food.Visibility
+	return s.repository.Import(foodImport)
+}
+
 func NewFoodService(repository domain.FoodRepository) domain.FoodService {
 	return &service{
 		repository: repository,
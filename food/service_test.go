@@ -1,6 +1,8 @@
 package food
 
 import (
+	"math"
+	"strings"
 	"testing"
 	"what_cook/domain"
 	"what_cook/gorm"
@@ -80,3 +82,330 @@ func TestService_Delete(t *testing.T) {
 		t.Error("test food is not deleted")
 	}
 }
+
+func TestService_List(t *testing.T) {
+	// check invalid page
+	_, err := foodService.List(domain.FoodListQuery{Page: domain.Page{Sort: "unknown"}})
+	if err != domain.InvalidPageError {
+		t.Error("err is not equal error ", domain.InvalidPageError)
+	}
+	// check default limit
+	list, err := foodService.List(domain.FoodListQuery{})
+	if err != nil {
+		t.Error(err)
+	}
+	if list.Limit != domain.DefaultPageLimit || list.Total == 0 {
+		t.Error("foods are not listed")
+	}
+}
+
+func TestService_GetNutrition(t *testing.T) {
+	food := domain.Food{
+		Name:     "test_food" + helper.RandomName(),
+		Servings: 2,
+		IngredientWeights: []domain.IngredientWeight{
+			{
+				Ingredient: domain.Ingredient{
+					Name:      "test_ingredient" + helper.RandomName(),
+					Nutrients: domain.Nutrients{Calories: 200, Protein: 10},
+				},
+				Weight: 0.5,
+			},
+		},
+	}
+	foodService.Save(&food)
+	// check nutrition
+	savedFood, err := foodService.Get(food.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if savedFood.Nutrition == nil {
+		t.Fatal("nutrition is not computed")
+	}
+	if savedFood.Nutrition.Total.Calories != 1000 || savedFood.Nutrition.PerServing.Protein != 25 {
+		t.Error("wrong nutrition")
+	}
+}
+
+func TestService_Nutrition(t *testing.T) {
+	food := gorm.RandomFood()
+	food.IngredientWeights = append(food.IngredientWeights, domain.IngredientWeight{
+		Ingredient: domain.Ingredient{
+			Name:      "test_ingredient" + helper.RandomName(),
+			Nutrients: domain.Nutrients{Calories: 500},
+		},
+		Weight: 0.2,
+	})
+	foodService.Save(&food)
+	// check report
+	report, err := foodService.Nutrition(food.ID, domain.Nutrients{Calories: 2000})
+	if err != nil {
+		t.Error(err)
+	}
+	if report.PerServingIntake.Calories != 50 {
+		t.Error("wrong reference intake percent")
+	}
+	// random ingredient has no nutrient data
+	if !report.Incomplete || len(report.IngredientsWithoutData) != 1 {
+		t.Error("ingredient without nutrient data is not reported")
+	}
+}
+
+func TestService_SaveQuantity(t *testing.T) {
+	food := domain.Food{
+		Name: "test_food" + helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{
+			{
+				Ingredient: domain.Ingredient{Name: "test_ingredient" + helper.RandomName(), PieceWeight: 60},
+				Quantity:   &domain.Quantity{Amount: 2, Unit: "pieces"},
+			},
+		},
+	}
+	err := foodService.Save(&food)
+	if err != nil {
+		t.Error(err)
+	}
+	// check canonical weight and entered unit
+	savedFood, err := foodService.Get(food.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	ingredientWeight := savedFood.IngredientWeights[0]
+	if math.Abs(ingredientWeight.Weight-0.12) > 1e-9 {
+		t.Error("quantity is not converted to kg")
+	}
+	if ingredientWeight.Quantity == nil || ingredientWeight.Quantity.Unit != "piece" ||
+		math.Abs(ingredientWeight.Quantity.Amount-2) > 1e-9 {
+		t.Error("quantity is not returned in entered unit")
+	}
+	// check unconvertible unit
+	food = gorm.RandomFood()
+	food.IngredientWeights[0].Quantity = &domain.Quantity{Amount: 1, Unit: "cup"}
+	if err := foodService.Save(&food); err != domain.UnconvertibleUnitError {
+		t.Error("err is not equal error ", domain.UnconvertibleUnitError)
+	}
+}
+
+func TestService_Steps(t *testing.T) {
+	food := gorm.RandomFood()
+	food.Steps = []domain.Step{{Text: "mix"}, {Text: "bake", Duration: 30}}
+	if err := foodService.Save(&food); err != nil {
+		t.Fatal(err)
+	}
+	ingredient := food.IngredientWeights[0].Ingredient
+	// check insert at position with ingredient reference
+	step := domain.Step{
+		Text:        "chop",
+		Position:    1,
+		Ingredients: []domain.Ingredient{{Model: ingredient.Model}},
+		Equipment:   domain.StringList{"knife", "board"},
+	}
+	if err := foodService.SaveStep(food.ID, &step); err != nil {
+		t.Error(err)
+	}
+	savedFood, err := foodService.Get(food.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := make([]string, len(savedFood.Steps))
+	for i, s := range savedFood.Steps {
+		texts[i] = s.Text
+	}
+	if strings.Join(texts, ",") != "chop,mix,bake" {
+		t.Error("wrong steps order ", texts)
+	}
+	chop := savedFood.Steps[0]
+	if len(chop.Ingredients) != 1 || !chop.Ingredients[0].Equal(&ingredient) || len(chop.Equipment) != 2 {
+		t.Error("step references are not saved")
+	}
+	// check ingredient which is not in the food
+	err = foodService.SaveStep(food.ID, &domain.Step{Text: "salt", Ingredients: []domain.Ingredient{{Model: testFood.IngredientWeights[0].Ingredient.Model}}})
+	if err != domain.StepIngredientError {
+		t.Error("err is not equal error ", domain.StepIngredientError)
+	}
+	// check reorder
+	mix, bake := savedFood.Steps[1], savedFood.Steps[2]
+	if _, err := foodService.ReorderSteps(food.ID, []uint{mix.ID, bake.ID}); err != domain.InvalidStepOrderError {
+		t.Error("err is not equal error ", domain.InvalidStepOrderError)
+	}
+	steps, err := foodService.ReorderSteps(food.ID, []uint{mix.ID, chop.ID, bake.ID})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(steps) != 3 || steps[0].ID != mix.ID || steps[1].Position != 2 {
+		t.Error("steps are not reordered")
+	}
+	// check other food
+	if err := foodService.DeleteStep(testFood.ID, chop.ID); err != domain.ModelNotFoundError {
+		t.Error("step is deleted by other food")
+	}
+	// check delete
+	if err := foodService.DeleteStep(food.ID, mix.ID); err != nil {
+		t.Error(err)
+	}
+	steps, _ = foodService.Steps(food.ID)
+	if len(steps) != 2 || steps[0].ID != chop.ID || steps[0].Position != 1 || steps[1].Position != 2 {
+		t.Error("step is not deleted")
+	}
+}
+
+func TestService_Reviews(t *testing.T) {
+	food := gorm.RandomFood()
+	foodService.Save(&food)
+	// check score
+	if err := foodService.SaveReview(food.ID, &domain.Review{Score: 0}); err != domain.InvalidScoreError {
+		t.Error("err is not equal error ", domain.InvalidScoreError)
+	}
+	// check food
+	if err := foodService.SaveReview(0, &domain.Review{Score: 3}); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	first := &domain.Review{AuthorID: 1, Score: 5, Text: "great"}
+	second := &domain.Review{AuthorID: 2, Score: 2}
+	for _, review := range []*domain.Review{first, second} {
+		if err := foodService.SaveReview(food.ID, review); err != nil {
+			t.Fatal(err)
+		}
+	}
+	savedFood, _ := foodService.Get(food.ID)
+	if savedFood.Rating.Count != 2 || savedFood.Rating.Average != 3.5 || savedFood.Rating.Distribution[4] != 1 {
+		t.Error("wrong rating ", savedFood.Rating)
+	}
+	// check the second review of the author
+	again := &domain.Review{AuthorID: 1, Score: 5, Text: "still great"}
+	if err := foodService.SaveReview(food.ID, again); err != nil {
+		t.Error(err)
+	}
+	if again.ID != first.ID {
+		t.Error("second review of the author is added")
+	}
+	if reviews, _ := foodService.Reviews(food.ID); len(reviews) != 2 {
+		t.Error("second review of the author is added ", reviews)
+	}
+	// check update
+	if err := foodService.UpdateReview(food.ID, second.ID, &domain.Review{Score: 4, Text: "better"}); err != nil {
+		t.Error(err)
+	}
+	if err := foodService.UpdateReview(testFood.ID, second.ID, &domain.Review{Score: 4}); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	reviews, err := foodService.Reviews(food.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(reviews) != 2 || reviews[0].ID != second.ID || reviews[0].Score != 4 || reviews[0].Text != "better" {
+		t.Error("review is not updated")
+	}
+	// check delete
+	if err := foodService.DeleteReview(food.ID, first.ID); err != nil {
+		t.Error(err)
+	}
+	savedFood, _ = foodService.Get(food.ID)
+	if savedFood.Rating.Count != 1 || savedFood.Rating.Average != 4 {
+		t.Error("rating is not updated ", savedFood.Rating)
+	}
+	// review again after delete
+	if err := foodService.SaveReview(food.ID, &domain.Review{AuthorID: 1, Score: 3}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestService_ShoppingList(t *testing.T) {
+	ingredient := domain.Ingredient{Name: "test_ingredient" + helper.RandomName(), Aisle: "bakery"}
+	food := domain.Food{
+		Name:     "test_food" + helper.RandomName(),
+		Servings: 2,
+		IngredientWeights: []domain.IngredientWeight{
+			{Ingredient: ingredient, Weight: 0.4},
+		},
+	}
+	if err := foodService.Save(&food); err != nil {
+		t.Fatal(err)
+	}
+	query := domain.ShoppingListQuery{
+		Foods: []domain.ShoppingFood{{FoodID: food.ID, Servings: 4}},
+		Have:  []domain.IngredientQuantity{{Name: ingredient.Name, Weight: 0.3}, {Name: "unknown" + helper.RandomName()}},
+	}
+	list, err := foodService.ShoppingList(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.UnresolvedIngredients) != 1 {
+		t.Error("unknown ingredient is not reported")
+	}
+	if len(list.Aisles) != 1 || list.Aisles[0].Aisle != "bakery" || len(list.Aisles[0].Items) != 1 {
+		t.Fatal("wrong aisles ", list.Aisles)
+	}
+	if item := list.Aisles[0].Items[0]; math.Abs(item.Needed-0.8) > 1e-9 || math.Abs(item.Weight-0.5) > 1e-9 {
+		t.Error("weight is not scaled to servings ", item.Needed, item.Weight)
+	}
+	// check private food of another user
+	private := gorm.RandomFood()
+	private.OwnerID = 1
+	private.Visibility = domain.PrivateFood
+	foodService.Save(&private)
+	query.Foods = []domain.ShoppingFood{{FoodID: private.ID}}
+	if _, err := foodService.ShoppingList(query); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+}
+
+func TestService_Import(t *testing.T) {
+	existing := gorm.RandomFood()
+	if err := foodService.Save(&existing); err != nil {
+		t.Fatal(err)
+	}
+	ingredient := existing.IngredientWeights[0].Ingredient
+	newFood := "test_food" + helper.RandomName()
+	newIngredient := "test_ingredient" + helper.RandomName()
+	rows := []domain.ImportRow{
+		{Food: newFood, Ingredient: ingredient.Name, Amount: 200, Unit: "g"},
+		{Food: newFood, Ingredient: newIngredient},
+		{Food: existing.Name, Ingredient: ingredient.Name, Amount: 0.1, Unit: "kg"},
+		{Food: existing.Name, Ingredient: ingredient.Name, Amount: 0.3},
+		{Food: newFood, Ingredient: "test_ingredient" + helper.RandomName(), Amount: 2, Unit: "pieces"},
+		{Food: newFood, Ingredient: ""},
+		{Food: " " + strings.ToUpper(newFood) + " ", Ingredient: newIngredient, Amount: 50, Unit: "g"},
+		{Food: existing.Name, Ingredient: ingredient.Name},
+	}
+	report, err := foodService.Import(domain.FoodImport{Rows: rows})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := []domain.ImportStatus{domain.ImportCreated, domain.ImportCreated, domain.ImportSkipped,
+		domain.ImportUpdated, domain.ImportFailed, domain.ImportFailed, domain.ImportUpdated, domain.ImportSkipped}
+	for i, status := range statuses {
+		if report.Rows[i].Status != status {
+			t.Errorf("row %d is %s, not %s (%s)", i+1, report.Rows[i].Status, status, report.Rows[i].Error)
+		}
+	}
+	if report.Created != 2 || report.Updated != 2 || report.Skipped != 2 || report.Failed != 2 {
+		t.Error("wrong counts ", report)
+	}
+	// check the existing ingredient is reused and the food is created once
+	first, second := report.Rows[0], report.Rows[1]
+	if first.IngredientID != ingredient.ID || first.IngredientCreated || !second.IngredientCreated {
+		t.Error("ingredients are not upserted by name")
+	}
+	if !first.FoodCreated || second.FoodCreated || first.FoodID != second.FoodID {
+		t.Error("food is not created once")
+	}
+	if renamed := report.Rows[6]; renamed.FoodCreated || renamed.FoodID != first.FoodID {
+		t.Error("food is not found by the name in another case")
+	}
+	food, err := foodService.Get(first.FoodID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(food.IngredientWeights) != 2 || math.Abs(food.IngredientWeights[0].Weight-0.2) > 1e-9 {
+		t.Error("wrong imported ingredients ", food.IngredientWeights)
+	}
+	updated, _ := foodService.Get(existing.ID)
+	if math.Abs(updated.IngredientWeights[0].Weight-0.3) > 1e-9 {
+		t.Error("ingredient weight is not updated or is overwritten by an unknown amount")
+	}
+	// check visibility
+	if _, err := foodService.Import(domain.FoodImport{Rows: rows, Visibility: "hidden"}); err != domain.InvalidVisibilityError {
+		t.Error("err is not equal error ", domain.InvalidVisibilityError)
+	}
+}
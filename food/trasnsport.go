@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/go-kit/kit/endpoint"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"mime"
 	"net/http"
 	"what_cook/domain"
 	"what_cook/helper"
@@ -15,56 +17,159 @@ import (
 
 var badRequest = errors.New("bad request")
 
-func MakeHandler(foodService domain.FoodService, logger kitlog.Logger) http.Handler {
+// MakeHandler makes food routes, authenticate makes middlewares which put the caller into the context
+// and check the token scope, reads are allowed for anonymous callers
+func MakeHandler(foodService domain.FoodService, authenticate func(domain.Scope) endpoint.Middleware, logger kitlog.Logger) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
 	}
 	foodHandler := kithttp.NewServer(
-		makeFoodEndpoint(foodService),
+		authenticate(domain.ReadScope)(makeFoodEndpoint(foodService)),
 		decodeFoodRequest,
 		encodeResponse,
 		opts...,
 	)
 	createFoodHandler := kithttp.NewServer(
-		makeCreateFoodEndpoint(foodService),
+		authenticate(domain.WriteScope)(makeCreateFoodEndpoint(foodService)),
 		decodeCreateFoodRequest,
 		encodeResponse,
 		opts...,
 	)
 	updateFoodHandler := kithttp.NewServer(
-		makeUpdateFoodEndpoint(foodService),
+		authenticate(domain.WriteScope)(makeUpdateFoodEndpoint(foodService)),
 		decodeUpdateFoodRequest,
 		encodeResponse,
 		opts...,
 	)
 	deleteFoodHandler := kithttp.NewServer(
-		makeDeleteFoodEndpoint(foodService),
+		authenticate(domain.WriteScope)(makeDeleteFoodEndpoint(foodService)),
 		decodeDeleteFoodRequest,
 		encodeResponse,
 		opts...,
 	)
 	foodsByIngredientsHandler := kithttp.NewServer(
-		makeFoodsByIngredientEndpoint(foodService),
+		authenticate(domain.ReadScope)(makeFoodsByIngredientEndpoint(foodService)),
 		decodeFoodsByIngredientsRequest,
 		encodeResponse,
 		opts...,
 	)
+	listFoodsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeListFoodsEndpoint(foodService)),
+		decodeListFoodsRequest,
+		encodeResponse,
+		opts...,
+	)
+	foodNutritionHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeFoodNutritionEndpoint(foodService)),
+		decodeFoodNutritionRequest,
+		encodeResponse,
+		opts...,
+	)
+	stepsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeStepsEndpoint(foodService)),
+		decodeStepsRequest,
+		encodeResponse,
+		opts...,
+	)
+	createStepHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCreateStepEndpoint(foodService)),
+		decodeCreateStepRequest,
+		encodeResponse,
+		opts...,
+	)
+	updateStepHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeUpdateStepEndpoint(foodService)),
+		decodeUpdateStepRequest,
+		encodeResponse,
+		opts...,
+	)
+	deleteStepHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeleteStepEndpoint(foodService)),
+		decodeDeleteStepRequest,
+		encodeResponse,
+		opts...,
+	)
+	reorderStepsHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeReorderStepsEndpoint(foodService)),
+		decodeReorderStepsRequest,
+		encodeResponse,
+		opts...,
+	)
+	reviewsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeReviewsEndpoint(foodService)),
+		decodeReviewsRequest,
+		encodeResponse,
+		opts...,
+	)
+	createReviewHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCreateReviewEndpoint(foodService)),
+		decodeCreateReviewRequest,
+		encodeResponse,
+		opts...,
+	)
+	updateReviewHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeUpdateReviewEndpoint(foodService)),
+		decodeUpdateReviewRequest,
+		encodeResponse,
+		opts...,
+	)
+	deleteReviewHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeleteReviewEndpoint(foodService)),
+		decodeDeleteReviewRequest,
+		encodeResponse,
+		opts...,
+	)
+	shoppingListHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeShoppingListEndpoint(foodService)),
+		decodeShoppingListRequest,
+		encodeResponse,
+		opts...,
+	)
+	importHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeImportEndpoint(foodService)),
+		decodeImportRequest,
+		encodeResponse,
+		opts...,
+	)
 
 	router := mux.NewRouter()
 	router.Handle("/food/{id}", foodHandler).Methods("GET")
+	router.Handle("/food/{id}/nutrition", foodNutritionHandler).Methods("GET")
+	router.Handle("/food/", listFoodsHandler).Methods("GET")
 	router.Handle("/food/", createFoodHandler).Methods("POST")
 	router.Handle("/food/{id}", updateFoodHandler).Methods("PUT")
 	router.Handle("/food/{id}", deleteFoodHandler).Methods("DELETE")
 	router.Handle("/food/byIngredients/", foodsByIngredientsHandler).Methods("GET")
+	router.Handle("/food/{id}/steps", stepsHandler).Methods("GET")
+	router.Handle("/food/{id}/steps", createStepHandler).Methods("POST")
+	router.Handle("/food/{id}/steps", reorderStepsHandler).Methods("PUT")
+	router.Handle("/food/{id}/steps/{stepId}", updateStepHandler).Methods("PUT")
+	router.Handle("/food/{id}/steps/{stepId}", deleteStepHandler).Methods("DELETE")
+	router.Handle("/food/{id}/reviews", reviewsHandler).Methods("GET")
+	router.Handle("/food/{id}/reviews", createReviewHandler).Methods("POST")
+	router.Handle("/food/{id}/reviews/{reviewId}", updateReviewHandler).Methods("PUT")
+	router.Handle("/food/{id}/reviews/{reviewId}", deleteReviewHandler).Methods("DELETE")
+	router.Handle("/food/shoppingList/", shoppingListHandler).Methods("GET", "POST")
+	router.Handle("/food/import/", importHandler).Methods("POST")
 	return router
 }
 
 func decodeFoodRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	if id, err := helper.GetRequestParam(r, "id"); err == nil {
-		return foodRequest{id}, nil
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
 	}
-	return nil, badRequest
+	unit := r.URL.Query().Get("unit")
+	if _, err := domain.ParseUnit(unit); unit != "" && err != nil {
+		return nil, badRequest
+	}
+	servings, err := helper.GetQueryInt(r, "servings", 0)
+	if err != nil || servings < 0 {
+		return nil, badRequest
+	}
+	return foodRequest{id, unit, uint(servings)}, nil
 }
 
 func decodeCreateFoodRequest(_ context.Context, r *http.Request) (interface{}, error) {
@@ -72,6 +177,9 @@ func decodeCreateFoodRequest(_ context.Context, r *http.Request) (interface{}, e
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	if request.Food == nil {
+		return nil, badRequest
+	}
 	return request, nil
 }
 
@@ -80,7 +188,7 @@ func decodeUpdateFoodRequest(_ context.Context, r *http.Request) (interface{}, e
 		var body struct {
 			Food *domain.Food `json:"food"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.Food != nil {
 			return updateFoodRequest{id, body.Food}, nil
 		}
 	}
@@ -99,19 +207,254 @@ func decodeFoodsByIngredientsRequest(_ context.Context, r *http.Request) (interf
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	for _, ingredient := range request.Ingredients {
+		if ingredient.Quantity == nil {
+			continue
+		}
+		if _, err := domain.ParseUnit(ingredient.Quantity.Unit); err != nil {
+			return nil, badRequest
+		}
+	}
+	scorer, err := domain.ScorerByName(r.URL.Query().Get("scorer"))
+	if err != nil {
+		return nil, badRequest
+	}
+	request.Scorer = scorer
 	return request, nil
 }
 
+func decodeListFoodsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var (
+		query domain.FoodListQuery
+		err   error
+	)
+	query.Offset, query.Limit, query.Sort, query.Desc, err = helper.GetQueryPage(r)
+	if err != nil {
+		return nil, badRequest
+	}
+	query.NameContains = r.URL.Query().Get("name")
+	query.Ingredient = r.URL.Query().Get("ingredient")
+	if query.CreatedAfter, err = helper.GetQueryTime(r, "createdAfter"); err != nil {
+		return nil, badRequest
+	}
+	if query.MinCalories, err = helper.GetQueryFloat(r, "minCalories"); err != nil {
+		return nil, badRequest
+	}
+	if query.MaxCalories, err = helper.GetQueryFloat(r, "maxCalories"); err != nil {
+		return nil, badRequest
+	}
+	if query.AnyTags, err = helper.GetQueryIds(r, "tags"); err != nil {
+		return nil, badRequest
+	}
+	if query.AllTags, err = helper.GetQueryIds(r, "allTags"); err != nil {
+		return nil, badRequest
+	}
+	if query.ExcludeTags, err = helper.GetQueryIds(r, "excludeTags"); err != nil {
+		return nil, badRequest
+	}
+	return listFoodsRequest{query}, nil
+}
+
+// decodeFoodNutritionRequest reads the reference intake from query parameters,
+// nutrients which are not set have default values
+func decodeFoodNutritionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	request := foodNutritionRequest{ID: id, ReferenceIntake: domain.DefaultReferenceIntake}
+	referenceIntake := map[string]*float64{
+		"calories":     &request.ReferenceIntake.Calories,
+		"protein":      &request.ReferenceIntake.Protein,
+		"fat":          &request.ReferenceIntake.Fat,
+		"carbohydrate": &request.ReferenceIntake.Carbohydrate,
+		"fibre":        &request.ReferenceIntake.Fibre,
+		"sugar":        &request.ReferenceIntake.Sugar,
+		"salt":         &request.ReferenceIntake.Salt,
+	}
+	for param, value := range referenceIntake {
+		v, err := helper.GetQueryFloat(r, param)
+		if err != nil || (v != nil && *v < 0) {
+			return nil, badRequest
+		}
+		if v != nil {
+			*value = *v
+		}
+	}
+	return request, nil
+}
+
+func decodeStepsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return stepsRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeCreateStepRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		var body struct {
+			Step domain.Step `json:"step"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			return createStepRequest{id, body.Step}, nil
+		}
+	}
+	return nil, badRequest
+}
+
+func decodeUpdateStepRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	stepId, err := helper.GetRequestParam(r, "stepId")
+	if err != nil {
+		return nil, badRequest
+	}
+	var body struct {
+		Step domain.Step `json:"step"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return updateStepRequest{id, stepId, body.Step}, nil
+}
+
+func decodeDeleteStepRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	stepId, err := helper.GetRequestParam(r, "stepId")
+	if err != nil {
+		return nil, badRequest
+	}
+	return deleteStepRequest{id, stepId}, nil
+}
+
+// decodeReorderStepsRequest reads step ids in the new order
+func decodeReorderStepsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	var body struct {
+		Steps []uint `json:"steps"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return reorderStepsRequest{id, body.Steps}, nil
+}
+
+func decodeReviewsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return reviewsRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeCreateReviewRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		var body struct {
+			Review domain.Review `json:"review"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			return createReviewRequest{id, body.Review}, nil
+		}
+	}
+	return nil, badRequest
+}
+
+func decodeUpdateReviewRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	reviewId, err := helper.GetRequestParam(r, "reviewId")
+	if err != nil {
+		return nil, badRequest
+	}
+	var body struct {
+		Review domain.Review `json:"review"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return updateReviewRequest{id, reviewId, body.Review}, nil
+}
+
+func decodeDeleteReviewRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	reviewId, err := helper.GetRequestParam(r, "reviewId")
+	if err != nil {
+		return nil, badRequest
+	}
+	return deleteReviewRequest{id, reviewId}, nil
+}
+
+func decodeShoppingListRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request shoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, badRequest
+	}
+	if len(request.Foods) == 0 {
+		return nil, badRequest
+	}
+	for _, food := range request.Foods {
+		if food.FoodID == 0 {
+			return nil, badRequest
+		}
+	}
+	for _, ingredient := range request.Have {
+		if ingredient.Quantity == nil {
+			continue
+		}
+		if _, err := domain.ParseUnit(ingredient.Quantity.Unit); err != nil {
+			return nil, badRequest
+		}
+	}
+	return request, nil
+}
+
+// decodeImportRequest reads csv rows if the content type is text/csv, a json array of rows otherwise
+func decodeImportRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	rows, err := domain.ReadImportRows(r.Body, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	return importRequest{rows, domain.Visibility(r.URL.Query().Get("visibility"))}, nil
+}
+
 type errorer interface {
 	error() error
 }
 
+// exporter is a response which can be rendered in a text media type accepted by the caller
+type exporter interface {
+	exported() domain.Exportable
+}
+
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	e, ok := response.(errorer)
 	if ok && e.error() != nil {
 		encodeError(ctx, e.error(), w)
 		return nil
 	}
+	if x, ok := response.(exporter); ok {
+		// the representation depends on the accept header, caches must not mix them
+		w.Header().Set("Vary", "Accept")
+		accept, _ := ctx.Value(kithttp.ContextKeyRequestAccept).(string)
+		if mediaType := helper.NegotiateMediaType(accept, domain.ExportMediaTypes...); mediaType != "" {
+			w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+			return x.exported().Export(w, mediaType)
+		}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
@@ -119,8 +462,14 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	switch err {
-	case badRequest:
+	case badRequest, domain.InvalidPageError, domain.UnknownUnitError, domain.UnconvertibleUnitError,
+		domain.StepIngredientError, domain.InvalidStepOrderError, domain.UnknownAllergenError, domain.UnknownDietError,
+		domain.InvalidVisibilityError, domain.InvalidScoreError, domain.InvalidImportError:
 		w.WriteHeader(http.StatusBadRequest)
+	case domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
 	case domain.ModelNotFoundError:
 		w.WriteHeader(http.StatusNotFound)
 	default:
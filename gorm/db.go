@@ -16,11 +16,27 @@ func SqliteDbSession(dsn string) *gorm.DB {
 		panic(dberr)
 	}
 
-	dberr = db.AutoMigrate(&domain.Food{}, &domain.Ingredient{}, &domain.IngredientWeight{})
+	dberr = db.AutoMigrate(&domain.Food{}, &domain.Ingredient{}, &domain.IngredientWeight{},
+		&domain.IngredientAlias{}, &domain.IngredientSubstitute{}, &domain.Pantry{}, &domain.PantryItem{},
+		&domain.Step{}, &domain.Tag{}, &domain.User{}, &domain.Token{}, &domain.Favourite{}, &domain.CookedEvent{}, &domain.Review{},
+		&domain.MealPlan{}, &domain.MealSlot{})
 	if dberr != nil {
 		panic(dberr)
 	}
 
+	// normalize names of ingredients created before name normalization
+	var ingredients []domain.Ingredient
+	dberr = db.Where("normalized_name IS NULL OR normalized_name = ?", "").Find(&ingredients).Error
+	if dberr != nil {
+		panic(dberr)
+	}
+	for _, ingredient := range ingredients {
+		dberr = db.Model(&ingredient).Update("normalized_name", domain.NormalizeIngredientName(ingredient.Name)).Error
+		if dberr != nil {
+			panic(dberr)
+		}
+	}
+
 	return db
 }
 
@@ -32,8 +48,8 @@ func CreateRandomIngredient(db *gorm.DB) domain.Ingredient {
 
 func RandomIngredient() domain.Ingredient {
 	return domain.Ingredient{
-		Name:     "test_ingredient" + helper.RandomName(),
-		Calories: 0,
+		Name:      "test_ingredient" + helper.RandomName(),
+		Nutrients: domain.Nutrients{Calories: 0},
 	}
 }
 
@@ -55,13 +71,87 @@ func RandomFood() domain.Food {
 	}
 }
 
+func CreateRandomPantry(db *gorm.DB) domain.Pantry {
+	randomPantry := RandomPantry()
+	db.Create(&randomPantry)
+	return randomPantry
+}
+
+func RandomPantry() domain.Pantry {
+	return domain.Pantry{
+		Name: "test_pantry" + helper.RandomName(),
+		Items: []domain.PantryItem{
+			{
+				Ingredient: RandomIngredient(),
+				Weight:     0.1,
+			},
+		},
+	}
+}
+
 func ClearData(db *gorm.DB) {
 	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
 		Unscoped().Delete(&domain.Ingredient{})
 
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.IngredientAlias{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.IngredientSubstitute{})
+
 	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
 		Unscoped().Delete(&domain.IngredientWeight{})
 
 	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
 		Unscoped().Delete(&domain.Food{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.Step{})
+
+	db.Exec("DELETE FROM step_ingredients")
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.Tag{})
+
+	db.Exec("DELETE FROM food_tags")
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.Review{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.MealSlot{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.MealPlan{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.Favourite{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.CookedEvent{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.User{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.Token{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.PantryItem{})
+
+	db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Unscoped().Delete(&domain.Pantry{})
+}
+
+func CreateRandomTag(db *gorm.DB) domain.Tag {
+	randomTag := RandomTag()
+	db.Create(&randomTag)
+	return randomTag
+}
+
+func RandomTag() domain.Tag {
+	return domain.Tag{
+		Name: "test_tag" + helper.RandomName(),
+		Kind: domain.CuisineTag,
+	}
 }
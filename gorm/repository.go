@@ -3,12 +3,15 @@ package gorm
 import (
 	"errors"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 	"what_cook/domain"
 )
 
 type CrudRepository struct {
 	Db       *gorm.DB
 	newModel func() interface{}
+	preloads []string
 }
 
 func (cr *CrudRepository) Save(model interface{}) error {
@@ -18,7 +21,11 @@ func (cr *CrudRepository) Save(model interface{}) error {
 
 func (cr *CrudRepository) Get(id uint) (interface{}, error) {
 	model := cr.newModel()
-	res := cr.Db.First(model, id)
+	db := cr.Db
+	for _, preload := range cr.preloads {
+		db = db.Preload(preload)
+	}
+	res := db.First(model, id)
 
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 		return nil, domain.ModelNotFoundError
@@ -48,10 +55,188 @@ func (cr *CrudRepository) Delete(id uint) error {
 	return res.Error
 }
 
+// paginate is a scope of sorting and pagination, columns are sql expressions by sort field
+func paginate(page domain.Page, columns map[string]string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		order := columns[page.Sort]
+		if page.Desc {
+			order += " DESC"
+		}
+		return db.Order(order).Offset(page.Offset).Limit(page.Limit)
+	}
+}
+
 type IngredientRepository struct {
 	CrudRepository
 }
 
+// Update replaces ingredient aliases when they are set
+func (ir *IngredientRepository) Update(id uint, model interface{}) error {
+	ingredient, ok := model.(*domain.Ingredient)
+	if !ok || ingredient.Aliases == nil {
+		return ir.CrudRepository.Update(id, model)
+	}
+	// check model
+	currentModel, e := ir.Get(id)
+	if e != nil {
+		return e
+	}
+	return ir.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(currentModel).Omit("Aliases").Updates(ingredient).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("ingredient_id = ?", id).Delete(&domain.IngredientAlias{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range ingredient.Aliases {
+			ingredient.Aliases[i].ID = 0
+			ingredient.Aliases[i].IngredientID = id
+		}
+		if len(ingredient.Aliases) == 0 {
+			return nil
+		}
+		return tx.Create(&ingredient.Aliases).Error
+	})
+}
+
+func (ir *IngredientRepository) Substitutes(ingredientID uint) ([]domain.IngredientSubstitute, error) {
+	substitutes := make([]domain.IngredientSubstitute, 0)
+	err := ir.Db.Preload("Ingredient").Preload("Substitute").
+		Where("ingredient_id = ?", ingredientID).
+		Find(&substitutes).Error
+	return substitutes, err
+}
+
+func (ir *IngredientRepository) GetSubstitute(id uint) (*domain.IngredientSubstitute, error) {
+	var substitute domain.IngredientSubstitute
+	res := ir.Db.Preload("Ingredient").Preload("Substitute").First(&substitute, id)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &substitute, res.Error
+}
+
+func (ir *IngredientRepository) SaveSubstitute(substitute *domain.IngredientSubstitute) error {
+	return ir.Db.Omit("Ingredient", "Substitute").Create(substitute).Error
+}
+
+func (ir *IngredientRepository) UpdateSubstitute(id uint, substitute *domain.IngredientSubstitute) error {
+	// check model
+	currentSubstitute, e := ir.GetSubstitute(id)
+	if e != nil {
+		return e
+	}
+	return ir.Db.Model(currentSubstitute).Omit("Ingredient", "Substitute").Updates(substitute).Error
+}
+
+func (ir *IngredientRepository) DeleteSubstitute(id uint) error {
+	// check model
+	substitute, e := ir.GetSubstitute(id)
+	if e != nil {
+		return e
+	}
+	return ir.Db.Delete(substitute, id).Error
+}
+
+// searchCandidates is how many candidates are ranked for every requested match,
+// maxSearchCandidates limits candidates of a search without a limit
+const (
+	searchCandidates    = 10
+	maxSearchCandidates = 500
+)
+
+// Search ranks ingredients which names or aliases start with the search prefix of the query
+// Search ranks candidates which contain the query, start with its first half or have a length close enough
+// for typos, every kind of candidates is limited
+func (ir *IngredientRepository) Search(query string, limit int) ([]domain.IngredientMatch, error) {
+	term := domain.SearchTerm(query)
+	if term == "" {
+		return make([]domain.IngredientMatch, 0), nil
+	}
+	candidates := maxSearchCandidates
+	if limit > 0 && limit*searchCandidates < candidates {
+		candidates = limit * searchCandidates
+	}
+	escaped, prefix := escapeLike(term), escapeLike(domain.SearchPrefix(query))
+	matched := "normalized_name LIKE ? ESCAPE '\\' OR normalized_name LIKE ? ESCAPE '\\' OR normalized_name LIKE ? ESCAPE '\\'"
+	ingredients, err := ir.findCandidates(candidates, matched, "%"+escaped+"%", prefix+"%", "% "+prefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	// typos in the first half of the query
+	if minLength, maxLength := domain.SearchLengths(query); maxLength != 0 {
+		typos, err := ir.findCandidates(maxSearchCandidates, "LENGTH(normalized_name) BETWEEN ? AND ?", minLength, maxLength)
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[uint]bool, len(ingredients))
+		for _, ingredient := range ingredients {
+			found[ingredient.ID] = true
+		}
+		for _, ingredient := range typos {
+			if !found[ingredient.ID] {
+				ingredients = append(ingredients, ingredient)
+			}
+		}
+	}
+	return domain.SearchIngredients(ingredients, query, limit), nil
+}
+
+// findCandidates returns the shortest ingredients whose names or aliases match the condition
+func (ir *IngredientRepository) findCandidates(limit int, condition string, args ...interface{}) ([]domain.Ingredient, error) {
+	aliasIngredientIds := ir.Db.Model(&domain.IngredientAlias{}).
+		Select("ingredient_id").
+		Where(condition, args...)
+	var ingredients []domain.Ingredient
+	err := ir.Db.Preload("Aliases").
+		Where(condition+" OR id IN (?)", append(args, aliasIngredientIds)...).
+		Order("LENGTH(normalized_name), id").
+		Limit(limit).
+		Find(&ingredients).Error
+	return ingredients, err
+}
+
+// escapeLike escapes wildcards of a LIKE pattern with a backslash
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+func (ir *IngredientRepository) List(query domain.IngredientListQuery) (domain.IngredientList, error) {
+	list := domain.IngredientList{
+		Ingredients: make([]domain.Ingredient, 0),
+		Offset:      query.Offset,
+		Limit:       query.Limit,
+	}
+	filter := func(db *gorm.DB) *gorm.DB {
+		if query.NameContains != "" {
+			db = db.Where("name LIKE ? ESCAPE '\\'", "%"+escapeLike(query.NameContains)+"%")
+		}
+		if query.CreatedAfter != nil {
+			db = db.Where("created_at > ?", query.CreatedAfter)
+		}
+		if query.MinCalories != nil {
+			db = db.Where("calories >= ?", *query.MinCalories)
+		}
+		if query.MaxCalories != nil {
+			db = db.Where("calories <= ?", *query.MaxCalories)
+		}
+		return db
+	}
+	err := ir.Db.Model(&domain.Ingredient{}).Scopes(filter).Count(&list.Total).Error
+	if err != nil {
+		return list, err
+	}
+	err = ir.Db.Scopes(filter, paginate(query.Page, map[string]string{
+		"id":        "id",
+		"name":      "name",
+		"createdAt": "created_at",
+		"calories":  "calories",
+	})).Preload("Aliases").Find(&list.Ingredients).Error
+	return list, err
+}
+
 func NewIngredientRepository(db *gorm.DB) domain.IngredientRepository {
 	return &IngredientRepository{
 		CrudRepository{
@@ -59,6 +244,7 @@ func NewIngredientRepository(db *gorm.DB) domain.IngredientRepository {
 			newModel: func() interface{} {
 				return &domain.Ingredient{}
 			},
+			preloads: []string{"Aliases"},
 		},
 	}
 }
@@ -67,59 +253,587 @@ type FoodRepository struct {
 	CrudRepository
 }
 
-func (f *FoodRepository) FindByIngredients(ingredientNames []string) ([]domain.FoodRecommendation, error) {
+func (f *FoodRepository) FindByIngredients(query domain.FoodsByIngredientsQuery) (domain.FoodsByIngredientsResult, error) {
 	// ingredients data
-	var ingredients []domain.Ingredient
-	err := f.Db.Find(&ingredients, "name IN ?", ingredientNames).Error
+	resolved, err := resolveIngredients(f.Db, query.IngredientNames())
 	if err != nil {
-		return nil, err
+		return domain.FoodsByIngredientsResult{}, err
+	}
+	stock, unresolved := query.Stock(resolved)
+	ingredients := make([]domain.Ingredient, len(stock.Items))
+	for i, stockItem := range stock.Items {
+		ingredients[i] = stockItem.Ingredient
 	}
 	ingredientIds := make([]uint, len(ingredients))
 	for i, ingredient := range ingredients {
 		ingredientIds[i] = ingredient.ID
 	}
-	// order sorted by ingredients availability
-	type foodResult struct {
-		Id               uint
-		IngredientsCount uint
-		IngredientsHas   uint
-	}
-	var order []foodResult
-	err = f.Db.Table("food").
-		Unscoped().
-		Select("food.id as id",
-			"count(iw.id) as ingredients_count",
-			"count(has_iw.id) as ingredients_has").
-		Joins("JOIN ingredient_weights iw on food.id = iw.food_id").
-		Joins("LEFT OUTER JOIN ingredient_weights has_iw on "+
-			"food.id = has_iw.food_id and "+
-			"iw.id = has_iw.id and "+
-			"has_iw.ingredient_id IN ?", ingredientIds).
-		Group("name").
-		Having("ingredients_has > ?", 0).
-		Order("ingredients_has DESC").Order("ingredients_count").
-		Scan(&order).Error
+	// substitutes by available ingredients
+	err = f.Db.Preload("Ingredient").Preload("Substitute").
+		Where("substitute_id IN ?", ingredientIds).
+		Find(&stock.Substitutes).Error
 	if err != nil {
-		return nil, err
+		return domain.FoodsByIngredientsResult{}, err
+	}
+	substitutedIds := make([]uint, len(stock.Substitutes))
+	for i, substitute := range stock.Substitutes {
+		substitutedIds[i] = substitute.IngredientID
+	}
+	// foods which have at least one of ingredients
+	var foodIds []uint
+	err = f.Db.Model(&domain.IngredientWeight{}).
+		Distinct("food_id").
+		Where("ingredient_id IN ?", append(ingredientIds, substitutedIds...)).
+		Pluck("food_id", &foodIds).Error
+	if err != nil {
+		return domain.FoodsByIngredientsResult{}, err
 	}
 	// food data
-	foodIds := make([]uint, len(order))
-	for i, foodResult := range order {
-		foodIds[i] = foodResult.Id
+	var foods []domain.Food
+	db := f.Db.Preload("IngredientWeights.Ingredient").Preload("Tags").
+		Where("id IN ?", foodIds).
+		Scopes(visibleTo(query.ViewerID), tagFilter(query.TagFilter))
+	if query.MaxTotalMinutes != 0 {
+		db = db.Where("prep_minutes + cook_minutes BETWEEN 1 AND ?", query.MaxTotalMinutes)
+	}
+	if query.ExcludeAllergens != 0 {
+		db = db.Where("id NOT IN (?)", foodIngredientsSql(f.Db).
+			Where("(i.allergens & ?) != 0", query.ExcludeAllergens))
+	}
+	if query.Diet != 0 {
+		db = db.Where("id NOT IN (?)", foodIngredientsSql(f.Db).
+			Where("(i.diets & ?) != ?", query.Diet, query.Diet))
+	}
+	err = db.Find(&foods).Error
+	if err != nil {
+		return domain.FoodsByIngredientsResult{}, err
+	}
+	if err := f.applyRatings(foods); err != nil {
+		return domain.FoodsByIngredientsResult{}, err
 	}
+	// make foodRecommendations sorted by ingredients availability
+	foodRecommendations := make([]domain.FoodRecommendation, len(foods))
+	for i, food := range foods {
+		foodRecommendations[i] = domain.FoodToFoodRecommendation(food, stock)
+	}
+	if query.ViewerID != 0 && (query.BoostFavourites || query.RecentDays != 0) {
+		if err := f.applyHistory(query, foodRecommendations); err != nil {
+			return domain.FoodsByIngredientsResult{}, err
+		}
+	}
+	domain.RankFoodRecommendations(foodRecommendations, query.Scorer)
+	return domain.FoodsByIngredientsResult{
+		Foods:                 foodRecommendations,
+		UnresolvedIngredients: unresolved,
+		Facets:                domain.TagFacetsOf(foods),
+	}, nil
+}
 
-	foods := make([]domain.Food, len(order))
-	f.Db.Table("food").Preload("IngredientWeights.Ingredient").Where("id IN ?", foodIds).Find(&foods)
-	foodMap := make(map[uint]domain.Food)
-	for _, food := range foods {
-		foodMap[food.ID] = food
+// applyHistory marks favourites and last cooked times of the viewer
+func (f *FoodRepository) applyHistory(query domain.FoodsByIngredientsQuery, foodRecommendations []domain.FoodRecommendation) error {
+	var favouriteIds []uint
+	err := f.Db.Model(&domain.Favourite{}).
+		Where("user_id = ?", query.ViewerID).
+		Pluck("food_id", &favouriteIds).Error
+	if err != nil {
+		return err
+	}
+	var events []domain.CookedEvent
+	err = f.Db.Select("food_id", "cooked_at").
+		Where("user_id = ?", query.ViewerID).
+		Find(&events).Error
+	if err != nil {
+		return err
+	}
+	lastCooked := make(map[uint]time.Time)
+	for _, event := range events {
+		if cookedAt, ok := lastCooked[event.FoodID]; !ok || event.CookedAt.After(cookedAt) {
+			lastCooked[event.FoodID] = event.CookedAt
+		}
+	}
+	query.ApplyHistory(foodRecommendations, favouriteIds, lastCooked)
+	return nil
+}
+
+// Get returns the food with its rating
+func (f *FoodRepository) Get(id uint) (interface{}, error) {
+	model, err := f.CrudRepository.Get(id)
+	if err != nil {
+		return model, err
+	}
+	food := model.(*domain.Food)
+	foods := []domain.Food{*food}
+	if err := f.applyRatings(foods); err != nil {
+		return nil, err
+	}
+	food.Rating = foods[0].Rating
+	return food, nil
+}
+
+// applyRatings sets ratings of foods aggregated from reviews
+func (f *FoodRepository) applyRatings(foods []domain.Food) error {
+	if len(foods) == 0 {
+		return nil
+	}
+	foodIds := make([]uint, len(foods))
+	for i, food := range foods {
+		foodIds[i] = food.ID
+	}
+	var rows []struct {
+		FoodID uint
+		Score  uint
+		Count  int64
+	}
+	err := f.Db.Model(&domain.Review{}).
+		Select("food_id, score, COUNT(*) AS count").
+		Where("food_id IN ? AND deleted_at IS NULL", foodIds).
+		Group("food_id").Group("score").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	counts := make(map[uint]map[uint]int64)
+	for _, row := range rows {
+		if counts[row.FoodID] == nil {
+			counts[row.FoodID] = make(map[uint]int64)
+		}
+		counts[row.FoodID][row.Score] = row.Count
 	}
-	// make foodRecommendations
-	foodRecommendations := make([]domain.FoodRecommendation, len(order))
-	for i, foodResult := range order {
-		foodRecommendations[i] = domain.FoodToFoodRecommendation(foodMap[foodResult.Id], ingredients)
+	for i := range foods {
+		foods[i].Rating = domain.NewRating(counts[foods[i].ID])
 	}
-	return foodRecommendations, nil
+	return nil
+}
+
+func (f *FoodRepository) Save(model interface{}) error {
+	if food, ok := model.(*domain.Food); ok {
+		if err := f.applyQuantities(food.IngredientWeights); err != nil {
+			return err
+		}
+		if err := f.applyTags(food.Tags); err != nil {
+			return err
+		}
+	}
+	return f.CrudRepository.Save(model)
+}
+
+// Update does not change steps, they are updated by step methods, tags are replaced when they are set
+func (f *FoodRepository) Update(id uint, model interface{}) error {
+	food, ok := model.(*domain.Food)
+	if ok {
+		if err := f.applyQuantities(food.IngredientWeights); err != nil {
+			return err
+		}
+		if err := f.applyTags(food.Tags); err != nil {
+			return err
+		}
+	}
+	// check model
+	currentModel, e := f.Get(id)
+	if e != nil {
+		return e
+	}
+	return f.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(currentModel).Omit("Steps", "Tags").Updates(model).Error
+		if err != nil || !ok || food.Tags == nil {
+			return err
+		}
+		return tx.Model(currentModel).Association("Tags").Replace(food.Tags)
+	})
+}
+
+// applyTags loads tags by ids, tags are not created with foods
+func (f *FoodRepository) applyTags(tags []domain.Tag) error {
+	for i := range tags {
+		if err := f.Db.First(&tags[i], tags[i].ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ModelNotFoundError
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// visibleTo is a scope of foods which the user can see
+func visibleTo(viewerID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(owner_id = 0 OR visibility = ? OR owner_id = ?)", domain.PublicFood, viewerID)
+	}
+}
+
+// tagFilter is a scope of foods selected by tags
+func tagFilter(filter domain.TagFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(filter.AnyTags) > 0 {
+			db = db.Where("id IN (SELECT food_id FROM food_tags WHERE tag_id IN ?)", filter.AnyTags)
+		}
+		if len(filter.AllTags) > 0 {
+			db = db.Where("id IN (SELECT food_id FROM food_tags WHERE tag_id IN ? "+
+				"GROUP BY food_id HAVING COUNT(DISTINCT tag_id) = ?)", filter.AllTags, len(uniqueIds(filter.AllTags)))
+		}
+		if len(filter.ExcludeTags) > 0 {
+			db = db.Where("id NOT IN (SELECT food_id FROM food_tags WHERE tag_id IN ?)", filter.ExcludeTags)
+		}
+		return db
+	}
+}
+
+func uniqueIds(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func (f *FoodRepository) Steps(foodID uint) ([]domain.Step, error) {
+	steps := make([]domain.Step, 0)
+	err := f.Db.Preload("Ingredients").
+		Where("food_id = ?", foodID).
+		Order("position").
+		Find(&steps).Error
+	return steps, err
+}
+
+func (f *FoodRepository) GetStep(id uint) (*domain.Step, error) {
+	var step domain.Step
+	res := f.Db.Preload("Ingredients").First(&step, id)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &step, res.Error
+}
+
+func (f *FoodRepository) SaveStep(step *domain.Step) error {
+	return f.Db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&domain.Step{}).Where("food_id = ?", step.FoodID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if step.Position == 0 || int64(step.Position) > count {
+			step.Position = uint(count) + 1
+		}
+		// make place for the step
+		err = tx.Model(&domain.Step{}).
+			Where("food_id = ? AND position >= ?", step.FoodID, step.Position).
+			Update("position", gorm.Expr("position + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(step).Error
+	})
+}
+
+// UpdateStep does not change the step position, ingredients are replaced when they are set
+func (f *FoodRepository) UpdateStep(id uint, step *domain.Step) error {
+	// check model
+	currentStep, e := f.GetStep(id)
+	if e != nil {
+		return e
+	}
+	return f.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(currentStep).Omit("Position", "FoodID", "Ingredients").Updates(step).Error
+		if err != nil || step.Ingredients == nil {
+			return err
+		}
+		return tx.Model(currentStep).Association("Ingredients").Replace(step.Ingredients)
+	})
+}
+
+func (f *FoodRepository) DeleteStep(id uint) error {
+	// check model
+	step, e := f.GetStep(id)
+	if e != nil {
+		return e
+	}
+	return f.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(step).Association("Ingredients").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Delete(step, id).Error; err != nil {
+			return err
+		}
+		// close the gap
+		return tx.Model(&domain.Step{}).
+			Where("food_id = ? AND position > ?", step.FoodID, step.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+func (f *FoodRepository) ReorderSteps(foodID uint, stepIDs []uint) error {
+	return f.Db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range stepIDs {
+			err := tx.Model(&domain.Step{}).
+				Where("id = ? AND food_id = ?", id, foodID).
+				Update("position", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (f *FoodRepository) Reviews(foodID uint) ([]domain.Review, error) {
+	reviews := make([]domain.Review, 0)
+	err := f.Db.Preload("Author").Where("food_id = ?", foodID).
+		Order("created_at DESC").Order("id DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+func (f *FoodRepository) GetReview(id uint) (*domain.Review, error) {
+	var review domain.Review
+	res := f.Db.Preload("Author").First(&review, id)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &review, res.Error
+}
+
+func (f *FoodRepository) SaveReview(review *domain.Review) error {
+	return f.Db.Omit("Author").
+		Where("food_id = ? AND author_id = ?", review.FoodID, review.AuthorID).
+		Assign(map[string]interface{}{"score": review.Score, "text": review.Text}).
+		FirstOrCreate(review).Error
+}
+
+func (f *FoodRepository) UpdateReview(id uint, review *domain.Review) error {
+	// check model
+	currentReview, e := f.GetReview(id)
+	if e != nil {
+		return e
+	}
+	return f.Db.Model(currentReview).Select("Score", "Text").Updates(review).Error
+}
+
+// DeleteReview removes the review permanently, so the author can review the food again
+func (f *FoodRepository) DeleteReview(id uint) error {
+	// check model
+	review, e := f.GetReview(id)
+	if e != nil {
+		return e
+	}
+	return f.Db.Unscoped().Delete(review, id).Error
+}
+
+func (f *FoodRepository) ResolveIngredients(names []string) (map[string]domain.Ingredient, error) {
+	return resolveIngredients(f.Db, names)
+}
+
+// Import adds ingredients to foods by rows, an error of the database rolls back the whole import
+func (f *FoodRepository) Import(foodImport domain.FoodImport) (domain.ImportReport, error) {
+	report := domain.ImportReport{Rows: make([]domain.ImportRowReport, 0, len(foodImport.Rows))}
+	err := f.Db.Transaction(func(tx *gorm.DB) error {
+		for i, row := range foodImport.Rows {
+			rowReport := domain.ImportRowReport{Row: i + 1, Food: row.Food, Ingredient: row.Ingredient}
+			if err := importRow(tx, foodImport, row, &rowReport); err != nil {
+				return err
+			}
+			report.Add(rowReport)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.ImportReport{}, err
+	}
+	return report, nil
+}
+
+// importRow upserts the ingredient and the food of the row, a wrong row is reported as failed
+func importRow(tx *gorm.DB, foodImport domain.FoodImport, row domain.ImportRow, report *domain.ImportRowReport) error {
+	fail := func(err error) error {
+		report.Status = domain.ImportFailed
+		report.Error = err.Error()
+		return nil
+	}
+	if err := row.Check(); err != nil {
+		return fail(err)
+	}
+	// ingredient by name or alias, it is created only for a valid row
+	ingredientName := strings.TrimSpace(row.Ingredient)
+	resolved, err := resolveIngredients(tx, []string{ingredientName})
+	if err != nil {
+		return err
+	}
+	ingredient, found := resolved[domain.NormalizeIngredientName(ingredientName)]
+	ingredientWeight := domain.IngredientWeight{Quantity: row.Quantity()}
+	if err := ingredientWeight.ApplyQuantity(ingredient); err != nil {
+		return fail(err)
+	}
+	if !found {
+		ingredient = domain.Ingredient{Name: ingredientName}
+		if err := tx.Create(&ingredient).Error; err != nil {
+			return err
+		}
+		report.IngredientCreated = true
+	}
+	report.IngredientID = ingredient.ID
+	// food of the owner by the case insensitive name
+	foodName := strings.TrimSpace(row.Food)
+	var food domain.Food
+	res := tx.Where("LOWER(TRIM(name)) = ? AND owner_id = ?", strings.ToLower(foodName), foodImport.OwnerID).
+		Order("id").Limit(1).Find(&food)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		food = domain.Food{Name: foodName, OwnerID: foodImport.OwnerID, Visibility: foodImport.Visibility}
+		if err := tx.Create(&food).Error; err != nil {
+			return err
+		}
+		report.FoodCreated = true
+	}
+	report.FoodID = food.ID
+	// ingredient weight of the food
+	var current domain.IngredientWeight
+	res = tx.Where("food_id = ? AND ingredient_id = ?", food.ID, ingredient.ID).Order("id").Limit(1).Find(&current)
+	if res.Error != nil {
+		return res.Error
+	}
+	switch {
+	case res.RowsAffected == 0:
+		ingredientWeight.FoodID = food.ID
+		ingredientWeight.IngredientID = ingredient.ID
+		report.Status = domain.ImportCreated
+		return tx.Omit("Ingredient").Create(&ingredientWeight).Error
+	case ingredientWeight.Quantity == nil, current.SameQuantity(ingredientWeight):
+		// an unknown amount keeps the known weight
+		report.Status = domain.ImportSkipped
+		return nil
+	default:
+		report.Status = domain.ImportUpdated
+		return tx.Model(&current).Select("Weight", "Unit").Updates(&ingredientWeight).Error
+	}
+}
+
+// applyQuantities converts quantities to weights by saved or new ingredients
+func (f *FoodRepository) applyQuantities(ingredientWeights []domain.IngredientWeight) error {
+	for i := range ingredientWeights {
+		ingredientWeight := &ingredientWeights[i]
+		if ingredientWeight.Quantity == nil {
+			continue
+		}
+		ingredient := ingredientWeight.Ingredient
+		if ingredientWeight.IngredientID != 0 {
+			if err := f.Db.First(&ingredient, ingredientWeight.IngredientID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return domain.ModelNotFoundError
+				}
+				return err
+			}
+		}
+		if err := ingredientWeight.ApplyQuantity(ingredient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foodIngredientsSql selects food ids by ingredients aliased as i
+func foodIngredientsSql(db *gorm.DB) *gorm.DB {
+	return db.Table("ingredient_weights iw").
+		Select("iw.food_id").
+		Joins("JOIN ingredients i ON i.id = iw.ingredient_id").
+		Where("iw.deleted_at IS NULL")
+}
+
+// foodCaloriesSql is food energy, calories are per 100 g and weights are in kg
+const foodCaloriesSql = "(SELECT COALESCE(SUM(iw.weight * i.calories * 10), 0) " +
+	"FROM ingredient_weights iw JOIN ingredients i ON i.id = iw.ingredient_id " +
+	"WHERE iw.food_id = foods.id AND iw.deleted_at IS NULL)"
+
+func (f *FoodRepository) List(query domain.FoodListQuery) (domain.FoodList, error) {
+	list := domain.FoodList{
+		Foods:  make([]domain.Food, 0),
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	}
+	var ingredientId uint
+	if query.Ingredient != "" {
+		resolved, err := resolveIngredients(f.Db, []string{query.Ingredient})
+		if err != nil {
+			return list, err
+		}
+		ingredient, ok := resolved[domain.NormalizeIngredientName(query.Ingredient)]
+		if !ok {
+			return list, nil
+		}
+		ingredientId = ingredient.ID
+	}
+	filter := func(db *gorm.DB) *gorm.DB {
+		if query.NameContains != "" {
+			db = db.Where("name LIKE ? ESCAPE '\\'", "%"+escapeLike(query.NameContains)+"%")
+		}
+		if query.CreatedAfter != nil {
+			db = db.Where("created_at > ?", query.CreatedAfter)
+		}
+		if ingredientId != 0 {
+			db = db.Where("id IN (?)", f.Db.Model(&domain.IngredientWeight{}).
+				Select("food_id").
+				Where("ingredient_id = ?", ingredientId))
+		}
+		if query.MinCalories != nil {
+			db = db.Where(foodCaloriesSql+" >= ?", *query.MinCalories)
+		}
+		if query.MaxCalories != nil {
+			db = db.Where(foodCaloriesSql+" <= ?", *query.MaxCalories)
+		}
+		return db.Scopes(visibleTo(query.ViewerID), tagFilter(query.TagFilter))
+	}
+	err := f.Db.Model(&domain.Food{}).Scopes(filter).Count(&list.Total).Error
+	if err != nil {
+		return list, err
+	}
+	err = f.Db.Scopes(filter, paginate(query.Page, map[string]string{
+		"id":        "id",
+		"name":      "name",
+		"createdAt": "created_at",
+		"calories":  foodCaloriesSql,
+	})).Preload("IngredientWeights.Ingredient").Preload("Tags").Find(&list.Foods).Error
+	if err != nil {
+		return list, err
+	}
+	if err := f.applyRatings(list.Foods); err != nil {
+		return list, err
+	}
+	list.Facets, err = f.tagFacets(f.Db.Model(&domain.Food{}).Select("id").Scopes(filter))
+	return list, err
+}
+
+// tagFacets counts tags of foods selected by the subquery
+func (f *FoodRepository) tagFacets(foods *gorm.DB) ([]domain.TagFacet, error) {
+	var rows []struct {
+		TagID uint
+		Count int64
+	}
+	err := f.Db.Table("food_tags").
+		Select("tag_id, COUNT(*) AS count").
+		Where("food_id IN (?)", foods).
+		Group("tag_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	tagIds := make([]uint, len(rows))
+	for i, row := range rows {
+		counts[row.TagID] = row.Count
+		tagIds[i] = row.TagID
+	}
+	var tags []domain.Tag
+	if len(tagIds) > 0 {
+		if err := f.Db.Where("id IN ?", tagIds).Find(&tags).Error; err != nil {
+			return nil, err
+		}
+	}
+	return domain.NewTagFacets(tags, counts), nil
 }
 
 func NewFoodRepository(db *gorm.DB) domain.FoodRepository {
@@ -128,5 +842,365 @@ func NewFoodRepository(db *gorm.DB) domain.FoodRepository {
 		newModel: func() interface{} {
 			return &domain.Food{}
 		},
+		preloads: []string{"IngredientWeights.Ingredient", "Steps.Ingredients", "Tags"},
+	}}
+}
+
+type PantryRepository struct {
+	CrudRepository
+}
+
+// Update replaces pantry items when they are set
+func (p *PantryRepository) Update(id uint, model interface{}) error {
+	pantry, ok := model.(*domain.Pantry)
+	if !ok || pantry.Items == nil {
+		return p.CrudRepository.Update(id, model)
+	}
+	// check model
+	currentModel, e := p.Get(id)
+	if e != nil {
+		return e
+	}
+	return p.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(currentModel).Omit("Items").Updates(pantry).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("pantry_id = ?", id).Delete(&domain.PantryItem{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range pantry.Items {
+			pantry.Items[i].ID = 0
+			pantry.Items[i].PantryID = id
+		}
+		if len(pantry.Items) == 0 {
+			return nil
+		}
+		return tx.Omit("Ingredient").Create(&pantry.Items).Error
+	})
+}
+
+// Delete removes the pantry with its items
+func (p *PantryRepository) Delete(id uint) error {
+	// check model
+	pantry, e := p.Get(id)
+	if e != nil {
+		return e
+	}
+	return p.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("pantry_id = ?", id).Delete(&domain.PantryItem{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(pantry, id).Error
+	})
+}
+
+func NewPantryRepository(db *gorm.DB) domain.PantryRepository {
+	return &PantryRepository{CrudRepository{
+		Db: db,
+		newModel: func() interface{} {
+			return &domain.Pantry{}
+		},
+		preloads: []string{"Items.Ingredient"},
+	}}
+}
+
+type MealPlanRepository struct {
+	CrudRepository
+}
+
+// Save creates the plan with its slots, foods of slots are not saved
+func (m *MealPlanRepository) Save(model interface{}) error {
+	plan, ok := model.(*domain.MealPlan)
+	if !ok {
+		return m.CrudRepository.Save(model)
+	}
+	return m.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Slots").Create(plan).Error; err != nil {
+			return err
+		}
+		return createSlots(tx, plan.ID, plan.Slots)
+	})
+}
+
+// Update replaces plan slots when they are set
+func (m *MealPlanRepository) Update(id uint, model interface{}) error {
+	plan, ok := model.(*domain.MealPlan)
+	if !ok || plan.Slots == nil {
+		return m.CrudRepository.Update(id, model)
+	}
+	// check model
+	currentModel, e := m.Get(id)
+	if e != nil {
+		return e
+	}
+	return m.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(currentModel).Omit("Slots").Updates(plan).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("meal_plan_id = ?", id).Delete(&domain.MealSlot{}).Error
+		if err != nil {
+			return err
+		}
+		return createSlots(tx, id, plan.Slots)
+	})
+}
+
+// Delete removes the plan with its slots
+func (m *MealPlanRepository) Delete(id uint) error {
+	// check model
+	plan, e := m.Get(id)
+	if e != nil {
+		return e
+	}
+	return m.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("meal_plan_id = ?", id).Delete(&domain.MealSlot{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(plan, id).Error
+	})
+}
+
+func (m *MealPlanRepository) List(ownerID uint) ([]domain.MealPlan, error) {
+	plans := make([]domain.MealPlan, 0)
+	err := m.Db.Preload("Slots.Food").Where("owner_id = ?", ownerID).
+		Order("start_date DESC").Order("id DESC").
+		Find(&plans).Error
+	return plans, err
+}
+
+func createSlots(tx *gorm.DB, planID uint, slots []domain.MealSlot) error {
+	for i := range slots {
+		slots[i].ID = 0
+		slots[i].MealPlanID = planID
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+	return tx.Omit("Food").Create(&slots).Error
+}
+
+func NewMealPlanRepository(db *gorm.DB) domain.MealPlanRepository {
+	return &MealPlanRepository{CrudRepository{
+		Db: db,
+		newModel: func() interface{} {
+			return &domain.MealPlan{}
+		},
+		preloads: []string{"Slots.Food"},
+	}}
+}
+
+// resolveIngredients finds ingredients by normalized names and aliases,
+// returns a map of ingredients by normalized name, names have priority over aliases
+func resolveIngredients(db *gorm.DB, names []string) (map[string]domain.Ingredient, error) {
+	normalizedNames := make([]string, len(names))
+	for i, name := range names {
+		normalizedNames[i] = domain.NormalizeIngredientName(name)
+	}
+	var aliases []domain.IngredientAlias
+	err := db.Where("normalized_name IN ?", normalizedNames).Order("id").Find(&aliases).Error
+	if err != nil {
+		return nil, err
+	}
+	aliasIngredientIds := make([]uint, len(aliases))
+	for i, alias := range aliases {
+		aliasIngredientIds[i] = alias.IngredientID
+	}
+	var ingredients []domain.Ingredient
+	err = db.Where("normalized_name IN ?", normalizedNames).
+		Or("id IN ?", aliasIngredientIds).
+		Order("id").
+		Find(&ingredients).Error
+	if err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]domain.Ingredient)
+	ingredientsById := make(map[uint]domain.Ingredient)
+	for _, ingredient := range ingredients {
+		ingredientsById[ingredient.ID] = ingredient
+		if _, ok := resolved[ingredient.NormalizedName]; !ok {
+			resolved[ingredient.NormalizedName] = ingredient
+		}
+	}
+	for _, alias := range aliases {
+		ingredient, found := ingredientsById[alias.IngredientID]
+		if _, ok := resolved[alias.NormalizedName]; !ok && found {
+			resolved[alias.NormalizedName] = ingredient
+		}
+	}
+	return resolved, nil
+}
+
+type TagRepository struct {
+	CrudRepository
+}
+
+func (t *TagRepository) List(kind domain.TagKind) ([]domain.Tag, error) {
+	tags := make([]domain.Tag, 0)
+	db := t.Db.Order("kind").Order("name")
+	if kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+	err := db.Find(&tags).Error
+	return tags, err
+}
+
+// Delete removes the tag from foods
+func (t *TagRepository) Delete(id uint) error {
+	// check model
+	tag, e := t.Get(id)
+	if e != nil {
+		return e
+	}
+	return t.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM food_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag, id).Error
+	})
+}
+
+func NewTagRepository(db *gorm.DB) domain.TagRepository {
+	return &TagRepository{CrudRepository{
+		Db: db,
+		newModel: func() interface{} {
+			return &domain.Tag{}
+		},
 	}}
 }
+
+type UserRepository struct {
+	CrudRepository
+}
+
+func (u *UserRepository) FindByName(name string) (*domain.User, error) {
+	var user domain.User
+	res := u.Db.Where("name = ?", name).First(&user)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &user, res.Error
+}
+
+func (u *UserRepository) SaveToken(token *domain.Token) error {
+	return u.Db.Omit("User").Create(token).Error
+}
+
+func (u *UserRepository) FindToken(hash string, now time.Time) (*domain.Token, error) {
+	var token domain.Token
+	res := u.Db.Preload("User").
+		Where("hash = ? AND (expires_at IS NULL OR expires_at > ?)", hash, now).
+		First(&token)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &token, res.Error
+}
+
+func (u *UserRepository) GetToken(id uint) (*domain.Token, error) {
+	var token domain.Token
+	res := u.Db.First(&token, id)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &token, res.Error
+}
+
+func (u *UserRepository) DeleteToken(id uint) error {
+	// check model
+	token, e := u.GetToken(id)
+	if e != nil {
+		return e
+	}
+	return u.Db.Delete(token, id).Error
+}
+
+func (u *UserRepository) APIKeys(userID uint) ([]domain.Token, error) {
+	tokens := make([]domain.Token, 0)
+	err := u.Db.Where("user_id = ? AND expires_at IS NULL", userID).Find(&tokens).Error
+	return tokens, err
+}
+
+func NewUserRepository(db *gorm.DB) domain.UserRepository {
+	return &UserRepository{CrudRepository{
+		Db: db,
+		newModel: func() interface{} {
+			return &domain.User{}
+		},
+	}}
+}
+
+type HistoryRepository struct {
+	Db *gorm.DB
+}
+
+// Favourites returns only the food id if the owner made the food private after it was marked
+func (h *HistoryRepository) Favourites(userID uint) ([]domain.Favourite, error) {
+	favourites := make([]domain.Favourite, 0)
+	err := h.Db.Preload("Food").Where("user_id = ?", userID).Order("id").Find(&favourites).Error
+	for i := range favourites {
+		if !favourites[i].Food.VisibleTo(userID) {
+			favourites[i].Food = domain.Food{}
+		}
+	}
+	return favourites, err
+}
+
+func (h *HistoryRepository) SaveFavourite(favourite *domain.Favourite) error {
+	return h.Db.Omit("Food").
+		Where("user_id = ? AND food_id = ?", favourite.UserID, favourite.FoodID).
+		FirstOrCreate(favourite).Error
+}
+
+// DeleteFavourite removes the favourite permanently, so the food can be marked again
+func (h *HistoryRepository) DeleteFavourite(userID uint, foodID uint) error {
+	res := h.Db.Unscoped().Where("user_id = ? AND food_id = ?", userID, foodID).Delete(&domain.Favourite{})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return domain.ModelNotFoundError
+	}
+	return res.Error
+}
+
+// History returns only the food id if the owner made the food private after it was cooked
+func (h *HistoryRepository) History(userID uint) ([]domain.CookedEvent, error) {
+	events := make([]domain.CookedEvent, 0)
+	err := h.Db.Preload("Food").Where("user_id = ?", userID).
+		Order("cooked_at DESC").Order("id DESC").
+		Find(&events).Error
+	for i := range events {
+		if !events[i].Food.VisibleTo(userID) {
+			events[i].Food = domain.Food{}
+		}
+	}
+	return events, err
+}
+
+func (h *HistoryRepository) SaveCookedEvent(event *domain.CookedEvent) error {
+	return h.Db.Omit("Food").Create(event).Error
+}
+
+func (h *HistoryRepository) GetCookedEvent(id uint) (*domain.CookedEvent, error) {
+	var event domain.CookedEvent
+	res := h.Db.First(&event, id)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ModelNotFoundError
+	}
+	return &event, res.Error
+}
+
+func (h *HistoryRepository) DeleteCookedEvent(id uint) error {
+	// check model
+	event, e := h.GetCookedEvent(id)
+	if e != nil {
+		return e
+	}
+	return h.Db.Delete(event, id).Error
+}
+
+func NewHistoryRepository(db *gorm.DB) domain.HistoryRepository {
+	return &HistoryRepository{Db: db}
+}
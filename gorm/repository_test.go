@@ -2,7 +2,10 @@ package gorm
 
 import (
 	"gorm.io/gorm"
+	"math"
+	"strings"
 	"testing"
+	"time"
 	"what_cook/domain"
 	"what_cook/helper"
 )
@@ -73,6 +76,22 @@ func TestIngredientRepository_Update(t *testing.T) {
 	}
 }
 
+func TestIngredientRepository_UpdateName(t *testing.T) {
+	ingredient := CreateRandomIngredient(db)
+	name := "Renamed Ingredients " + helper.RandomName()
+	if err := ingredientRepository.Update(ingredient.ID, &domain.Ingredient{Name: name}); err != nil {
+		t.Fatal(err)
+	}
+	// check lookup by the new name
+	resolved, err := resolveIngredients(db, []string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, ok := resolved[domain.NormalizeIngredientName(name)]; !ok || found.ID != ingredient.ID {
+		t.Error("ingredient is not resolved by the new name")
+	}
+}
+
 func TestIngredientRepository_Delete(t *testing.T) {
 	err := ingredientRepository.Delete(testIngredient.ID)
 	if err != nil {
@@ -118,6 +137,26 @@ func TestFoodRepository_Save(t *testing.T) {
 	}
 }
 
+func TestFoodRepository_SaveFood(t *testing.T) {
+	food := RandomFood()
+	// check save
+	if err := foodRepository.Save(&food); err != nil {
+		t.Fatal(err)
+	}
+	// check availability with ingredient weights
+	food2, err := foodRepository.Get(food.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !food.Equal(food2) {
+		t.Error("food is not equal returned object")
+	}
+	ingredientWeights := food2.(*domain.Food).IngredientWeights
+	if len(ingredientWeights) != 1 || math.Abs(ingredientWeights[0].Weight-0.1) > 1e-9 {
+		t.Error("ingredient weight is not saved")
+	}
+}
+
 func TestFoodRepository_Update(t *testing.T) {
 	testFood.Name = "test_food" + helper.RandomName()
 	err := foodRepository.Update(testFood.ID, testFood)
@@ -166,18 +205,471 @@ func TestFoodRepository_FindByIngredients(t *testing.T) {
 		foodRepository.Save(foods[i])
 	}
 	// test
-	ingredientNames := make([]string, len(ingredients))
+	ingredientQuantities := make([]domain.IngredientQuantity, len(ingredients))
 	for i, ingredient := range ingredients {
-		ingredientNames[i] = ingredient.Name
+		ingredientQuantities[i] = domain.IngredientQuantity{Name: ingredient.Name}
 	}
-	r, err := foodRepository.FindByIngredients(ingredientNames)
+	result, err := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{Ingredients: ingredientQuantities})
 	if err != nil {
 		t.Error(err)
 	}
 	// check order
-	for i, foodRecommendation := range r {
+	for i, foodRecommendation := range result.Foods {
 		if !foodRecommendation.Food.Equal(foods[i]) {
 			t.Error("wrong order")
 		}
 	}
 }
+
+func TestFoodRepository_FindByIngredientsWeight(t *testing.T) {
+	// create test data
+	ingredient := CreateRandomIngredient(db)
+	needMore := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID, Weight: 0.5}},
+	}
+	needLess := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID, Weight: 0.1}},
+	}
+	foodRepository.Save(needMore)
+	foodRepository.Save(needLess)
+	// test
+	result, err := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients: []domain.IngredientQuantity{{Name: ingredient.Name, Weight: 0.2}},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	r := result.Foods
+	if len(r) != 2 {
+		t.Fatal("wrong foods count")
+	}
+	// check order
+	if !r[0].Food.Equal(needLess) || !r[1].Food.Equal(needMore) {
+		t.Error("wrong order")
+	}
+	// check shortage
+	if len(r[0].Shortages) != 0 || r[0].Coverage != 1 {
+		t.Error("food is fully covered")
+	}
+	if len(r[1].Shortages) != 1 || math.Abs(r[1].Shortages[0].Shortage-0.3) > 1e-9 {
+		t.Error("wrong shortage")
+	}
+	if math.Abs(r[1].Coverage-0.4) > 1e-9 {
+		t.Error("wrong coverage")
+	}
+}
+
+func TestFoodRepository_FindByIngredientsExpiring(t *testing.T) {
+	// create test data
+	fresh, expiring := CreateRandomIngredient(db), CreateRandomIngredient(db)
+	freshFood := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: fresh.ID}},
+	}
+	expiringFood := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: expiring.ID}},
+	}
+	foodRepository.Save(freshFood)
+	foodRepository.Save(expiringFood)
+	// test
+	date := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	freshBestBefore, expiringBestBefore := date.AddDate(0, 1, 0), date.AddDate(0, 0, 1)
+	result, err := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients: []domain.IngredientQuantity{
+			{Name: fresh.Name, BestBefore: &freshBestBefore},
+			{Name: expiring.Name, BestBefore: &expiringBestBefore},
+		},
+		Date: date,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	r := result.Foods
+	if len(r) != 2 {
+		t.Fatal("wrong foods count")
+	}
+	// check order
+	if !r[0].Food.Equal(expiringFood) || !r[1].Food.Equal(freshFood) {
+		t.Error("wrong order")
+	}
+	// check expiring ingredients
+	if len(r[0].ExpiringIngredients) != 1 || r[0].ExpiringIngredients[0].Ingredient.ID != expiring.ID {
+		t.Error("expiring ingredient is not reported")
+	}
+	if len(r[1].ExpiringIngredients) != 0 || r[1].Urgency != 0 {
+		t.Error("fresh ingredient is reported as expiring")
+	}
+}
+
+func TestFoodRepository_FindByIngredientsSubstitute(t *testing.T) {
+	// create test data
+	butter, margarine := CreateRandomIngredient(db), CreateRandomIngredient(db)
+	db.Create(&domain.IngredientSubstitute{IngredientID: butter.ID, SubstituteID: margarine.ID, Ratio: 2})
+	food := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: butter.ID, Weight: 0.1}},
+	}
+	foodRepository.Save(food)
+	// test
+	result, err := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients: []domain.IngredientQuantity{{Name: margarine.Name, Weight: 0.1}},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	r := result.Foods
+	if len(r) != 1 || !r[0].Food.Equal(food) {
+		t.Fatal("food is not found by substitute")
+	}
+	if len(r[0].HasIngredients) != 1 || len(r[0].Substitutions) != 1 {
+		t.Error("substitute is not used")
+	}
+	// ratio 2 needs 0.2 kg of margarine
+	if len(r[0].Shortages) != 1 || math.Abs(r[0].Shortages[0].Needed-0.2) > 1e-9 {
+		t.Error("substitute ratio is not used")
+	}
+}
+
+func TestFoodRepository_FindByIngredientsAliases(t *testing.T) {
+	// create test data
+	name := "Test Egg" + helper.RandomName()
+	ingredient := domain.Ingredient{
+		Name:    name,
+		Aliases: []domain.IngredientAlias{{Name: "hen " + name}},
+	}
+	ingredientRepository.Save(&ingredient)
+	food := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID, Weight: 0.2}},
+	}
+	foodRepository.Save(food)
+	// test
+	unknown := "unknown" + helper.RandomName()
+	result, err := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients: []domain.IngredientQuantity{
+			{Name: "  " + strings.ToUpper(name) + "s", Weight: 0.1},
+			{Name: "Hen " + name + "s", Weight: 0.1},
+			{Name: unknown},
+		},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(result.Foods) != 1 || !result.Foods[0].Food.Equal(food) {
+		t.Fatal("food is not found by normalized names")
+	}
+	// both names are the same ingredient
+	if result.Foods[0].Coverage != 1 {
+		t.Error("weights of the same ingredient are not summed")
+	}
+	if len(result.UnresolvedIngredients) != 1 || result.UnresolvedIngredients[0] != unknown {
+		t.Error("unresolved ingredient is not reported")
+	}
+}
+
+func TestFoodRepository_List(t *testing.T) {
+	// create test data
+	prefix := helper.RandomName()
+	ingredient := domain.Ingredient{Name: helper.RandomName(), Nutrients: domain.Nutrients{Calories: 100}}
+	ingredientRepository.Save(&ingredient)
+	foods := make([]*domain.Food, 3)
+	for i := range foods {
+		foods[i] = &domain.Food{
+			Name: prefix + helper.RandomName(),
+			// 100, 200 and 300 kcal
+			IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID, Weight: 0.1 * float64(i+1)}},
+		}
+		foodRepository.Save(foods[i])
+	}
+	// check filters
+	minCalories, maxCalories := 150.0, 350.0
+	list, err := foodRepository.List(domain.FoodListQuery{
+		Page:         domain.Page{Limit: 1, Sort: "calories", Desc: true},
+		NameContains: prefix,
+		Ingredient:   ingredient.Name,
+		MinCalories:  &minCalories,
+		MaxCalories:  &maxCalories,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if list.Total != 2 {
+		t.Error("wrong total count")
+	}
+	if len(list.Foods) != 1 || !list.Foods[0].Equal(foods[2]) {
+		t.Error("wrong page")
+	}
+}
+
+func TestIngredientRepository_List(t *testing.T) {
+	// create test data
+	prefix := helper.RandomName()
+	for i := 0; i < 3; i++ {
+		ingredient := domain.Ingredient{Name: prefix + helper.RandomName()}
+		ingredientRepository.Save(&ingredient)
+	}
+	// check pagination
+	list, err := ingredientRepository.List(domain.IngredientListQuery{
+		Page:         domain.Page{Offset: 1, Limit: 5, Sort: "id"},
+		NameContains: prefix,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if list.Total != 3 || len(list.Ingredients) != 2 {
+		t.Error("wrong page")
+	}
+	// check wildcards are escaped
+	list, err = ingredientRepository.List(domain.IngredientListQuery{
+		Page:         domain.Page{Limit: 5, Sort: "id"},
+		NameContains: "%_%",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if list.Total != 0 {
+		t.Error("wildcards match ingredients ", list.Total)
+	}
+}
+
+func TestFoodRepository_FindByIngredientsMaxTotalMinutes(t *testing.T) {
+	// create test data
+	ingredient := CreateRandomIngredient(db)
+	quickFood := &domain.Food{
+		Name:              helper.RandomName(),
+		PrepMinutes:       5,
+		CookMinutes:       10,
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID}},
+	}
+	slowFood := &domain.Food{
+		Name:              helper.RandomName(),
+		PrepMinutes:       15,
+		CookMinutes:       60,
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID}},
+	}
+	untimedFood := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: ingredient.ID}},
+	}
+	foodRepository.Save(quickFood)
+	foodRepository.Save(slowFood)
+	foodRepository.Save(untimedFood)
+	// test
+	result, err := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients:     []domain.IngredientQuantity{{Name: ingredient.Name}},
+		MaxTotalMinutes: 20,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	r := result.Foods
+	if len(r) != 1 || !r[0].Food.Equal(quickFood) {
+		t.Fatal("foods are not filtered by total time")
+	}
+	if r[0].Food.TotalMinutes != 15 {
+		t.Error("wrong total time")
+	}
+}
+
+func TestFoodRepository_ListTags(t *testing.T) {
+	// create test data
+	prefix := helper.RandomName()
+	italian, breakfast, spicy := CreateRandomTag(db), CreateRandomTag(db), CreateRandomTag(db)
+	tagSets := [][]domain.Tag{{italian}, {italian, breakfast}, {italian, breakfast, spicy}}
+	foods := make([]*domain.Food, len(tagSets))
+	for i, tags := range tagSets {
+		foods[i] = &domain.Food{Name: prefix + helper.RandomName(), Tags: tags}
+		if err := foodRepository.Save(foods[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var testData = []struct {
+		filter domain.TagFilter
+		total  int64
+	}{
+		{domain.TagFilter{AnyTags: []uint{breakfast.ID, spicy.ID}}, 2},
+		{domain.TagFilter{AllTags: []uint{italian.ID, breakfast.ID}}, 2},
+		{domain.TagFilter{AllTags: []uint{italian.ID}, ExcludeTags: []uint{spicy.ID}}, 2},
+		{domain.TagFilter{ExcludeTags: []uint{breakfast.ID}}, 1},
+	}
+	for _, testcase := range testData {
+		list, err := foodRepository.List(domain.FoodListQuery{
+			Page:         domain.Page{Limit: 10, Sort: "id"},
+			NameContains: prefix,
+			TagFilter:    testcase.filter,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if list.Total != testcase.total {
+			t.Errorf("%+v: total is %d, not %d", testcase.filter, list.Total, testcase.total)
+		}
+	}
+	// check facets
+	list, err := foodRepository.List(domain.FoodListQuery{
+		Page:         domain.Page{Limit: 1, Sort: "id"},
+		NameContains: prefix,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	counts := make(map[uint]int64)
+	for _, facet := range list.Facets {
+		counts[facet.Tag.ID] = facet.Count
+	}
+	if len(list.Facets) != 3 || counts[italian.ID] != 3 || counts[breakfast.ID] != 2 || counts[spicy.ID] != 1 {
+		t.Error("wrong facets ", list.Facets)
+	}
+	// check unknown tag
+	food := &domain.Food{Name: prefix, Tags: []domain.Tag{{Name: "unknown"}}}
+	if err := foodRepository.Save(food); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+}
+
+func TestFoodRepository_FindByIngredientsDiet(t *testing.T) {
+	// create test data
+	pasta := domain.Ingredient{Name: helper.RandomName(), Allergens: domain.Gluten, Diets: domain.Vegan | domain.Halal}
+	egg := domain.Ingredient{Name: helper.RandomName(), Allergens: domain.Egg, Diets: domain.Vegetarian | domain.Halal}
+	ingredientRepository.Save(&pasta)
+	ingredientRepository.Save(&egg)
+	carbonara := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: pasta.ID}, {IngredientID: egg.ID}},
+	}
+	aglioOlio := &domain.Food{
+		Name:              helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{{IngredientID: pasta.ID}},
+	}
+	foodRepository.Save(carbonara)
+	foodRepository.Save(aglioOlio)
+	var testData = []struct {
+		query domain.FoodsByIngredientsQuery
+		foods []*domain.Food
+	}{
+		{domain.FoodsByIngredientsQuery{}, []*domain.Food{aglioOlio, carbonara}},
+		{domain.FoodsByIngredientsQuery{ExcludeAllergens: domain.Egg | domain.Nuts}, []*domain.Food{aglioOlio}},
+		{domain.FoodsByIngredientsQuery{ExcludeAllergens: domain.Gluten}, []*domain.Food{}},
+		// pasta is vegan, so it is vegetarian
+		{domain.FoodsByIngredientsQuery{Diet: domain.Vegetarian}, []*domain.Food{aglioOlio, carbonara}},
+		{domain.FoodsByIngredientsQuery{Diet: domain.Vegan | domain.Halal}, []*domain.Food{aglioOlio}},
+		{domain.FoodsByIngredientsQuery{Diet: domain.Kosher}, []*domain.Food{}},
+	}
+	for i, testcase := range testData {
+		testcase.query.Ingredients = []domain.IngredientQuantity{{Name: pasta.Name}}
+		result, err := foodRepository.FindByIngredients(testcase.query)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(result.Foods) != len(testcase.foods) {
+			t.Errorf("%d: wrong foods count %d", i, len(result.Foods))
+			continue
+		}
+		for j, food := range testcase.foods {
+			if !result.Foods[j].Food.Equal(food) {
+				t.Errorf("%d: wrong food %d", i, j)
+			}
+		}
+	}
+	// check derived flags
+	result, _ := foodRepository.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients: []domain.IngredientQuantity{{Name: egg.Name}},
+	})
+	if len(result.Foods) != 1 || result.Foods[0].Food.Allergens != domain.Gluten|domain.Egg ||
+		result.Foods[0].Food.Diets != domain.Vegetarian|domain.Halal {
+		t.Error("wrong food flags")
+	}
+}
+
+func TestFoodRepository_ListVisibility(t *testing.T) {
+	// create test data
+	prefix := helper.RandomName()
+	foods := []*domain.Food{
+		{Name: prefix + "shared"},
+		{Name: prefix + "public", OwnerID: 1, Visibility: domain.PublicFood},
+		{Name: prefix + "private", OwnerID: 1, Visibility: domain.PrivateFood},
+		{Name: prefix + "other", OwnerID: 2, Visibility: domain.PrivateFood},
+	}
+	for _, food := range foods {
+		foodRepository.Save(food)
+	}
+	var testData = []struct {
+		viewerID uint
+		total    int64
+	}{
+		{0, 2},
+		{1, 3},
+		{2, 3},
+		{3, 2},
+	}
+	for _, testcase := range testData {
+		list, err := foodRepository.List(domain.FoodListQuery{
+			Page:         domain.Page{Limit: 10, Sort: "id"},
+			NameContains: prefix,
+			ViewerID:     testcase.viewerID,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if list.Total != testcase.total {
+			t.Errorf("user %d sees %d foods, not %d", testcase.viewerID, list.Total, testcase.total)
+		}
+	}
+}
+
+func TestPantryRepository_Delete(t *testing.T) {
+	pantryRepository := NewPantryRepository(db)
+	pantry := CreateRandomPantry(db)
+	if err := pantryRepository.Delete(pantry.ID); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&domain.PantryItem{}).Where("pantry_id = ?", pantry.ID).Count(&count)
+	if count != 0 {
+		t.Error("pantry items are not deleted")
+	}
+}
+
+func TestIngredientRepository_Search(t *testing.T) {
+	name := "searched" + helper.RandomName()
+	ingredient := domain.Ingredient{Name: "Green " + name}
+	if err := ingredientRepository.Save(&ingredient); err != nil {
+		t.Fatal(err)
+	}
+	// check word prefix with a typo at the end
+	query := name[:len(name)-1] + "z"
+	matches, err := ingredientRepository.Search(query, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Ingredient.ID != ingredient.ID {
+		t.Error("ingredient is not found by the word prefix ", matches)
+	}
+	// check substring
+	matches, err = ingredientRepository.Search(name[2:12], 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Ingredient.ID != ingredient.ID {
+		t.Error("ingredient is not found by the substring ", matches)
+	}
+	// check a typo at the start
+	typo := domain.Ingredient{Name: "typo" + helper.RandomName()[:8]}
+	if err := ingredientRepository.Save(&typo); err != nil {
+		t.Fatal(err)
+	}
+	matches, err = ingredientRepository.Search("tpyo"+typo.Name[4:], 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) == 0 || matches[0].Ingredient.ID != typo.ID {
+		t.Error("ingredient is not found with the typo at the start ", matches)
+	}
+	// check wildcards are escaped
+	if matches, _ := ingredientRepository.Search("%%%%", 5); len(matches) != 0 {
+		t.Error("wildcards match ingredients")
+	}
+}
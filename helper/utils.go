@@ -7,7 +7,9 @@ import (
 	"github.com/gorilla/mux"
 	"math/rand"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -41,3 +43,122 @@ func GetRequestParam(r *http.Request, param string) (uint, error) {
 	}
 	return 0, errors.New("parse error")
 }
+
+// GetQueryInt returns an integer query parameter or the default value if it is not set
+func GetQueryInt(r *http.Request, param string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// GetQueryIds returns comma separated ids of a query parameter or nil if it is not set
+func GetQueryIds(r *http.Request, param string) ([]uint, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	parts := strings.Split(value, ",")
+	ids := make([]uint, len(parts))
+	for i, part := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = uint(id)
+	}
+	return ids, nil
+}
+
+// GetQueryFloat returns a float query parameter or nil if it is not set
+func GetQueryFloat(r *http.Request, param string) (*float64, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetQueryTime returns a RFC 3339 or a date (2006-01-02) query parameter or nil if it is not set
+func GetQueryTime(r *http.Request, param string) (*time.Time, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetQueryPage returns offset, limit, sort field and descending order query parameters
+func GetQueryPage(r *http.Request) (offset int, limit int, sort string, desc bool, err error) {
+	if offset, err = GetQueryInt(r, "offset", 0); err != nil {
+		return
+	}
+	if limit, err = GetQueryInt(r, "limit", 0); err != nil {
+		return
+	}
+	sort = r.URL.Query().Get("sort")
+	switch r.URL.Query().Get("order") {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		err = errors.New("parse error")
+	}
+	return
+}
+
+// NegotiateMediaType returns the offered media type which is the most preferred by the Accept header,
+// it returns an empty string if the header is empty, prefers JSON or accepts nothing of the offers
+func NegotiateMediaType(accept string, offers ...string) string {
+	type acceptRange struct {
+		mediaType string
+		q         float64
+	}
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	for _, r := range ranges {
+		switch r.mediaType {
+		case "*/*", "application/*", "application/json":
+			return ""
+		}
+		for _, offer := range offers {
+			if r.mediaType == offer || r.mediaType == strings.SplitN(offer, "/", 2)[0]+"/*" {
+				return offer
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package history
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"gopkg.in/validator.v2"
+	"what_cook/domain"
+)
+
+type favouritesResponse struct {
+	Favourites []domain.Favourite
+	Err        error `json:"err,omitempty"`
+}
+
+func (f favouritesResponse) error() error {
+	return f.Err
+}
+
+func makeFavouritesEndpoint(hs domain.HistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if domain.ViewerFromContext(ctx) == nil {
+			return favouritesResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		favourites, e := hs.Favourites(domain.ViewerID(ctx))
+		return favouritesResponse{favourites, e}, nil
+	}
+}
+
+type addFavouriteRequest struct {
+	FoodID uint `validate:"nonzero"`
+}
+
+type addFavouriteResponse struct {
+	Favourite *domain.Favourite `json:",omitempty"`
+	Err       error             `json:"err,omitempty"`
+}
+
+func (a addFavouriteResponse) error() error {
+	return a.Err
+}
+
+func makeAddFavouriteEndpoint(hs domain.HistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(addFavouriteRequest)
+		if domain.ViewerFromContext(ctx) == nil {
+			return addFavouriteResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		if validateError := validator.Validate(req); validateError != nil {
+			return addFavouriteResponse{nil, validateError}, nil
+		}
+		favourite, e := hs.AddFavourite(domain.ViewerID(ctx), req.FoodID)
+		return addFavouriteResponse{favourite, e}, nil
+	}
+}
+
+type removeFavouriteRequest struct {
+	FoodID uint
+}
+
+type removeFavouriteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r removeFavouriteResponse) error() error {
+	return r.Err
+}
+
+func makeRemoveFavouriteEndpoint(hs domain.HistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(removeFavouriteRequest)
+		if domain.ViewerFromContext(ctx) == nil {
+			return removeFavouriteResponse{domain.UnauthenticatedError}, nil
+		}
+		e := hs.RemoveFavourite(domain.ViewerID(ctx), req.FoodID)
+		return removeFavouriteResponse{e}, nil
+	}
+}
+
+type historyResponse struct {
+	History []domain.CookedEvent
+	Err     error `json:"err,omitempty"`
+}
+
+func (h historyResponse) error() error {
+	return h.Err
+}
+
+func makeHistoryEndpoint(hs domain.HistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if domain.ViewerFromContext(ctx) == nil {
+			return historyResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		history, e := hs.History(domain.ViewerID(ctx))
+		return historyResponse{history, e}, nil
+	}
+}
+
+type cookRequest struct {
+	Event domain.CookedEvent
+}
+
+type cookResponse struct {
+	Event *domain.CookedEvent `json:",omitempty"`
+	Err   error               `json:"err,omitempty"`
+}
+
+func (c cookResponse) error() error {
+	return c.Err
+}
+
+func makeCookEndpoint(hs domain.HistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(cookRequest)
+		if domain.ViewerFromContext(ctx) == nil {
+			return cookResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		if validateError := validator.Validate(req); validateError != nil {
+			return cookResponse{nil, validateError}, nil
+		}
+		e := hs.Cook(domain.ViewerID(ctx), &req.Event)
+		if e != nil {
+			return cookResponse{nil, e}, nil
+		}
+		return cookResponse{&req.Event, nil}, nil
+	}
+}
+
+type deleteCookedEventRequest struct {
+	ID uint
+}
+
+type deleteCookedEventResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deleteCookedEventResponse) error() error {
+	return d.Err
+}
+
+func makeDeleteCookedEventEndpoint(hs domain.HistoryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteCookedEventRequest)
+		if domain.ViewerFromContext(ctx) == nil {
+			return deleteCookedEventResponse{domain.UnauthenticatedError}, nil
+		}
+		e := hs.DeleteCookedEvent(domain.ViewerID(ctx), req.ID)
+		return deleteCookedEventResponse{e}, nil
+	}
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"time"
+	"what_cook/domain"
+)
+
+type service struct {
+	repository  domain.HistoryRepository
+	foodService domain.FoodService
+	// now is the current time, it is replaced in tests
+	now func() time.Time
+}
+
+func (s *service) Favourites(userID uint) ([]domain.Favourite, error) {
+	return s.repository.Favourites(userID)
+}
+
+func (s *service) AddFavourite(userID uint, foodID uint) (*domain.Favourite, error) {
+	if err := s.checkFood(userID, foodID); err != nil {
+		return nil, err
+	}
+	favourite := &domain.Favourite{UserID: userID, FoodID: foodID}
+	return favourite, s.repository.SaveFavourite(favourite)
+}
+
+func (s *service) RemoveFavourite(userID uint, foodID uint) error {
+	return s.repository.DeleteFavourite(userID, foodID)
+}
+
+func (s *service) History(userID uint) ([]domain.CookedEvent, error) {
+	return s.repository.History(userID)
+}
+
+func (s *service) Cook(userID uint, event *domain.CookedEvent) error {
+	if err := event.CheckRating(); err != nil {
+		return err
+	}
+	if err := s.checkFood(userID, event.FoodID); err != nil {
+		return err
+	}
+	event.UserID = userID
+	if event.CookedAt.IsZero() {
+		event.CookedAt = s.now()
+	}
+	return s.repository.SaveCookedEvent(event)
+}
+
+// DeleteCookedEvent deletes the event only if it belongs to the user
+func (s *service) DeleteCookedEvent(userID uint, id uint) error {
+	event, err := s.repository.GetCookedEvent(id)
+	if err != nil {
+		return err
+	}
+	if event.UserID != userID {
+		return domain.ModelNotFoundError
+	}
+	return s.repository.DeleteCookedEvent(id)
+}
+
+// checkFood checks the food exists and the user can see it
+func (s *service) checkFood(userID uint, foodID uint) error {
+	food, err := s.foodService.Get(foodID)
+	if err != nil {
+		return err
+	}
+	if !food.VisibleTo(userID) {
+		return domain.ModelNotFoundError
+	}
+	return nil
+}
+
+func NewService(r domain.HistoryRepository, foodService domain.FoodService) domain.HistoryService {
+	return &service{repository: r, foodService: foodService, now: time.Now}
+}
@@ -0,0 +1,213 @@
+package history
+
+import (
+	"testing"
+	"time"
+	"what_cook/domain"
+	"what_cook/food"
+	"what_cook/gorm"
+	"what_cook/helper"
+)
+
+var (
+	historyService domain.HistoryService
+	foodService    domain.FoodService
+	testFood       domain.Food
+)
+
+const testUserID = 1
+
+func TestMain(m *testing.M) {
+	// setup
+	db := gorm.SqliteDbSession(gorm.DSN_SQLITE_TEST)
+	gorm.ClearData(db)
+	foodService = food.NewFoodService(gorm.NewFoodRepository(db))
+	historyService = NewService(gorm.NewHistoryRepository(db), foodService)
+	testFood = gorm.CreateRandomFood(db)
+	// run tests
+	m.Run()
+}
+
+func TestService_Favourites(t *testing.T) {
+	// check not found
+	if _, err := historyService.AddFavourite(testUserID, 0); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	// check private food of other user
+	private := gorm.RandomFood()
+	private.OwnerID = testUserID + 1
+	private.Visibility = domain.PrivateFood
+	foodService.Save(&private)
+	if _, err := historyService.AddFavourite(testUserID, private.ID); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	// add twice
+	if _, err := historyService.AddFavourite(testUserID, testFood.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := historyService.AddFavourite(testUserID, testFood.ID); err != nil {
+		t.Error(err)
+	}
+	favourites, err := historyService.Favourites(testUserID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(favourites) != 1 || !favourites[0].Food.Equal(&testFood) {
+		t.Error("wrong favourites")
+	}
+	// remove and add again
+	if err := historyService.RemoveFavourite(testUserID, testFood.ID); err != nil {
+		t.Error(err)
+	}
+	if err := historyService.RemoveFavourite(testUserID, testFood.ID); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	if _, err := historyService.AddFavourite(testUserID, testFood.ID); err != nil {
+		t.Error(err)
+	}
+	historyService.RemoveFavourite(testUserID, testFood.ID)
+}
+
+func TestService_Cook(t *testing.T) {
+	// check rating
+	if err := historyService.Cook(testUserID, &domain.CookedEvent{FoodID: testFood.ID, Rating: 6}); err != domain.InvalidRatingError {
+		t.Error("err is not equal error ", domain.InvalidRatingError)
+	}
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	old := &domain.CookedEvent{FoodID: testFood.ID, CookedAt: weekAgo, Rating: 3}
+	if err := historyService.Cook(testUserID, old); err != nil {
+		t.Fatal(err)
+	}
+	latest := &domain.CookedEvent{FoodID: testFood.ID, Rating: 5}
+	if err := historyService.Cook(testUserID, latest); err != nil {
+		t.Fatal(err)
+	}
+	if latest.CookedAt.IsZero() {
+		t.Error("cooked date is not set")
+	}
+	history, err := historyService.History(testUserID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(history) != 2 || history[0].ID != latest.ID || history[1].ID != old.ID {
+		t.Fatal("wrong history")
+	}
+	// check other user event
+	if err := historyService.DeleteCookedEvent(testUserID+1, old.ID); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	if err := historyService.DeleteCookedEvent(testUserID, old.ID); err != nil {
+		t.Error(err)
+	}
+	history, _ = historyService.History(testUserID)
+	if len(history) != 1 {
+		t.Error("event is not deleted")
+	}
+	historyService.DeleteCookedEvent(testUserID, latest.ID)
+}
+
+func TestService_Recommendations(t *testing.T) {
+	// foods with the same ingredient
+	ingredient := gorm.RandomIngredient()
+	favourite := domain.Food{Name: helper.RandomName(), IngredientWeights: []domain.IngredientWeight{{Ingredient: ingredient}}}
+	foodService.Save(&favourite)
+	cooked := domain.Food{Name: helper.RandomName(), IngredientWeights: []domain.IngredientWeight{{IngredientID: favourite.IngredientWeights[0].IngredientID}}}
+	foodService.Save(&cooked)
+	other := domain.Food{Name: helper.RandomName(), IngredientWeights: []domain.IngredientWeight{{IngredientID: favourite.IngredientWeights[0].IngredientID}}}
+	foodService.Save(&other)
+	historyService.AddFavourite(testUserID, favourite.ID)
+	historyService.Cook(testUserID, &domain.CookedEvent{FoodID: cooked.ID, CookedAt: time.Now().AddDate(0, 0, -1)})
+
+	var testData = []struct {
+		query domain.FoodsByIngredientsQuery
+		foods []domain.Food
+	}{
+		{domain.FoodsByIngredientsQuery{}, []domain.Food{favourite, cooked, other}},
+		{domain.FoodsByIngredientsQuery{ViewerID: testUserID, BoostFavourites: true}, []domain.Food{favourite, cooked, other}},
+		{domain.FoodsByIngredientsQuery{ViewerID: testUserID, RecentDays: 3}, []domain.Food{favourite, other, cooked}},
+		// cooked more than a day ago
+		{domain.FoodsByIngredientsQuery{ViewerID: testUserID, RecentDays: 1}, []domain.Food{favourite, cooked, other}},
+		// anonymous queries are not personalised
+		{domain.FoodsByIngredientsQuery{BoostFavourites: true, RecentDays: 3}, []domain.Food{favourite, cooked, other}},
+	}
+	for i, testcase := range testData {
+		testcase.query.Ingredients = []domain.IngredientQuantity{{Name: ingredient.Name}}
+		result, err := foodService.FindByIngredients(testcase.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Foods) != len(testcase.foods) {
+			t.Fatalf("%d: wrong foods count %d", i, len(result.Foods))
+		}
+		for j, food := range testcase.foods {
+			if !result.Foods[j].Food.Equal(&food) {
+				t.Errorf("%d: wrong food %d", i, j)
+			}
+		}
+	}
+	result, _ := foodService.FindByIngredients(domain.FoodsByIngredientsQuery{
+		Ingredients: []domain.IngredientQuantity{{Name: ingredient.Name}},
+		ViewerID:    testUserID,
+		RecentDays:  3,
+	})
+	if !result.Foods[0].Favourite || result.Foods[2].LastCooked == nil {
+		t.Error("history is not marked")
+	}
+}
+
+func TestService_FavouritesDiet(t *testing.T) {
+	userID := uint(testUserID + 2)
+	meat := domain.Food{
+		Name: "test_food" + helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{
+			{Ingredient: domain.Ingredient{Name: "test_ingredient" + helper.RandomName(), Diets: domain.Halal}, Weight: 0.3},
+		},
+	}
+	if err := foodService.Save(&meat); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := historyService.AddFavourite(userID, meat.ID); err != nil {
+		t.Fatal(err)
+	}
+	favourites, err := historyService.Favourites(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favourites) != 1 || favourites[0].Food.Diets&domain.Vegan != 0 || favourites[0].Food.Diets&domain.Vegetarian != 0 {
+		t.Error("meat dish is vegan ", favourites)
+	}
+}
+
+func TestService_FavouritesPrivate(t *testing.T) {
+	userID := uint(testUserID + 3)
+	shared := gorm.RandomFood()
+	shared.OwnerID = userID + 1
+	shared.Visibility = domain.PublicFood
+	if err := foodService.Save(&shared); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := historyService.AddFavourite(userID, shared.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := historyService.Cook(userID, &domain.CookedEvent{FoodID: shared.ID}); err != nil {
+		t.Fatal(err)
+	}
+	// owner makes the food private
+	if err := foodService.Update(shared.ID, &domain.Food{Visibility: domain.PrivateFood}); err != nil {
+		t.Fatal(err)
+	}
+	favourites, err := historyService.Favourites(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favourites) != 1 || favourites[0].FoodID != shared.ID || favourites[0].Food.Name != "" {
+		t.Error("private food is returned in favourites ", favourites)
+	}
+	history, err := historyService.History(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].FoodID != shared.ID || history[0].Food.Name != "" {
+		t.Error("private food is returned in history ", history)
+	}
+}
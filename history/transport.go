@@ -0,0 +1,137 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"what_cook/domain"
+	"what_cook/helper"
+)
+
+var badRequest = errors.New("bad request")
+
+// MakeHandler makes routes of favourites and cooking history of the caller,
+// authenticate makes middlewares which put the caller into the context and check the token scope
+func MakeHandler(hs domain.HistoryService, authenticate func(domain.Scope) endpoint.Middleware, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
+	}
+	favouritesHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeFavouritesEndpoint(hs)),
+		kithttp.NopRequestDecoder,
+		encodeResponse,
+		opts...,
+	)
+	addFavouriteHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeAddFavouriteEndpoint(hs)),
+		decodeAddFavouriteRequest,
+		encodeResponse,
+		opts...,
+	)
+	removeFavouriteHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeRemoveFavouriteEndpoint(hs)),
+		decodeRemoveFavouriteRequest,
+		encodeResponse,
+		opts...,
+	)
+	historyHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeHistoryEndpoint(hs)),
+		kithttp.NopRequestDecoder,
+		encodeResponse,
+		opts...,
+	)
+	cookHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCookEndpoint(hs)),
+		decodeCookRequest,
+		encodeResponse,
+		opts...,
+	)
+	deleteCookedEventHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeleteCookedEventEndpoint(hs)),
+		decodeDeleteCookedEventRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/me/favourites", favouritesHandler).Methods("GET")
+	router.Handle("/me/favourites", addFavouriteHandler).Methods("POST")
+	router.Handle("/me/favourites/{foodId}", removeFavouriteHandler).Methods("DELETE")
+	router.Handle("/me/history", historyHandler).Methods("GET")
+	router.Handle("/me/history", cookHandler).Methods("POST")
+	router.Handle("/me/history/{id}", deleteCookedEventHandler).Methods("DELETE")
+	return router
+}
+
+func decodeAddFavouriteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		Favourite addFavouriteRequest `json:"favourite"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Favourite.FoodID == 0 {
+		return nil, badRequest
+	}
+	return body.Favourite, nil
+}
+
+func decodeRemoveFavouriteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if foodID, err := helper.GetRequestParam(r, "foodId"); err == nil {
+		return removeFavouriteRequest{foodID}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeCookRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request cookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Event.FoodID == 0 {
+		return nil, badRequest
+	}
+	return request, nil
+}
+
+func decodeDeleteCookedEventRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return deleteCookedEventRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(errorer)
+	if ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch err {
+	case badRequest, domain.InvalidRatingError:
+		w.WriteHeader(http.StatusBadRequest)
+	case domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
+	case domain.ModelNotFoundError:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError) // TODO: debug true|false, logging
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
@@ -21,6 +21,10 @@ func (i ingredientResponse) error() error {
 	return i.Err
 }
 
+func (i ingredientResponse) exported() domain.Exportable {
+	return i.Ingredient
+}
+
 func makeIngredientEndpoint(is domain.IngredientService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		var req, _ = request.(ingredientRequest)
@@ -45,6 +49,9 @@ func (c createIngredientResponse) error() error {
 func makeCreateIngredientEndpoint(is domain.IngredientService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		var req = request.(createIngredientRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return createIngredientResponse{"", roleError}, nil
+		}
 		if error := validator.Validate(req); error != nil {
 			return createIngredientResponse{"", error}, nil
 		}
@@ -69,6 +76,9 @@ func (u updateIngredientResponse) error() error {
 func makeUpdateIngredientEndpoint(is domain.IngredientService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		var req = request.(updateIngredientRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return updateIngredientResponse{roleError}, nil
+		}
 		if error := validator.Validate(req); error != nil {
 			return updateIngredientResponse{error}, nil
 		}
@@ -92,7 +102,151 @@ func (d deleteIngredientResponse) error() error {
 func makeDeleteIngredientEndpoint(is domain.IngredientService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		var req = request.(deleteIngredientRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return deleteIngredientResponse{roleError}, nil
+		}
 		deleteError := is.Delete(req.ID)
 		return deleteIngredientResponse{deleteError}, nil
 	}
 }
+
+type substitutesRequest struct {
+	IngredientID uint
+}
+
+type substitutesResponse struct {
+	Substitutes []domain.IngredientSubstitute
+	Err         error `json:"err,omitempty"`
+}
+
+func (s substitutesResponse) error() error {
+	return s.Err
+}
+
+func makeSubstitutesEndpoint(is domain.IngredientService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		var req = request.(substitutesRequest)
+		substitutes, e := is.Substitutes(req.IngredientID)
+		return substitutesResponse{substitutes, e}, nil
+	}
+}
+
+type createSubstituteRequest struct {
+	IngredientID uint
+	Substitute   domain.IngredientSubstitute
+}
+
+type createSubstituteResponse struct {
+	SubstituteID string
+	Err          error `json:"err,omitempty"`
+}
+
+func (c createSubstituteResponse) error() error {
+	return c.Err
+}
+
+func makeCreateSubstituteEndpoint(is domain.IngredientService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		var req = request.(createSubstituteRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return createSubstituteResponse{"", roleError}, nil
+		}
+		if error := validator.Validate(req); error != nil {
+			return createSubstituteResponse{"", error}, nil
+		}
+		saveError := is.SaveSubstitute(req.IngredientID, &req.Substitute)
+		return createSubstituteResponse{strconv.Itoa(int(req.Substitute.ID)), saveError}, nil
+	}
+}
+
+type updateSubstituteRequest struct {
+	IngredientID uint
+	ID           uint
+	Substitute   domain.IngredientSubstitute
+}
+
+type updateSubstituteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (u updateSubstituteResponse) error() error {
+	return u.Err
+}
+
+func makeUpdateSubstituteEndpoint(is domain.IngredientService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		var req = request.(updateSubstituteRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return updateSubstituteResponse{roleError}, nil
+		}
+		e := is.UpdateSubstitute(req.IngredientID, req.ID, &req.Substitute)
+		return updateSubstituteResponse{e}, nil
+	}
+}
+
+type deleteSubstituteRequest struct {
+	IngredientID uint
+	ID           uint
+}
+
+type deleteSubstituteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deleteSubstituteResponse) error() error {
+	return d.Err
+}
+
+func makeDeleteSubstituteEndpoint(is domain.IngredientService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		var req = request.(deleteSubstituteRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return deleteSubstituteResponse{roleError}, nil
+		}
+		deleteError := is.DeleteSubstitute(req.IngredientID, req.ID)
+		return deleteSubstituteResponse{deleteError}, nil
+	}
+}
+
+type searchIngredientsRequest struct {
+	Query string
+	Limit int
+}
+
+type searchIngredientsResponse struct {
+	Ingredients []domain.IngredientMatch
+	Err         error `json:"err,omitempty"`
+}
+
+func (s searchIngredientsResponse) error() error {
+	return s.Err
+}
+
+func makeSearchIngredientsEndpoint(is domain.IngredientService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		var req = request.(searchIngredientsRequest)
+		matches, e := is.Search(req.Query, req.Limit)
+		return searchIngredientsResponse{matches, e}, nil
+	}
+}
+
+type listIngredientsRequest struct {
+	Query domain.IngredientListQuery
+}
+
+type listIngredientsResponse struct {
+	domain.IngredientList
+	Err error `json:"err,omitempty"`
+}
+
+func (l listIngredientsResponse) error() error {
+	return l.Err
+}
+
+func makeListIngredientsEndpoint(is domain.IngredientService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		var req = request.(listIngredientsRequest)
+		list, e := is.List(req.Query)
+		return listIngredientsResponse{list, e}, nil
+	}
+}
@@ -28,6 +28,69 @@ func (s *service) Delete(id uint) error {
 	return s.ingredientRepository.Delete(id)
 }
 
+func (s *service) Substitutes(ingredientID uint) ([]domain.IngredientSubstitute, error) {
+	// check ingredient
+	if _, e := s.Get(ingredientID); e != nil {
+		return nil, e
+	}
+	return s.ingredientRepository.Substitutes(ingredientID)
+}
+
+func (s *service) SaveSubstitute(ingredientID uint, substitute *domain.IngredientSubstitute) error {
+	// check ingredients
+	if _, e := s.Get(ingredientID); e != nil {
+		return e
+	}
+	if _, e := s.Get(substitute.SubstituteID); e != nil {
+		return e
+	}
+	substitute.IngredientID = ingredientID
+	return s.ingredientRepository.SaveSubstitute(substitute)
+}
+
+func (s *service) UpdateSubstitute(ingredientID uint, id uint, substitute *domain.IngredientSubstitute) error {
+	if _, e := s.getSubstitute(ingredientID, id); e != nil {
+		return e
+	}
+	if substitute.SubstituteID != 0 {
+		if _, e := s.Get(substitute.SubstituteID); e != nil {
+			return e
+		}
+	}
+	substitute.IngredientID = ingredientID
+	return s.ingredientRepository.UpdateSubstitute(id, substitute)
+}
+
+func (s *service) DeleteSubstitute(ingredientID uint, id uint) error {
+	if _, e := s.getSubstitute(ingredientID, id); e != nil {
+		return e
+	}
+	return s.ingredientRepository.DeleteSubstitute(id)
+}
+
+func (s *service) Search(query string, limit int) ([]domain.IngredientMatch, error) {
+	return s.ingredientRepository.Search(query, limit)
+}
+
+func (s *service) List(query domain.IngredientListQuery) (domain.IngredientList, error) {
+	if err := query.Page.Validate(domain.IngredientSortFields...); err != nil {
+		return domain.IngredientList{}, err
+	}
+	return s.ingredientRepository.List(query)
+}
+
+// getSubstitute returns the substitute only if it belongs to the ingredient
+func (s *service) getSubstitute(ingredientID uint, id uint) (*domain.IngredientSubstitute, error) {
+	substitute, e := s.ingredientRepository.GetSubstitute(id)
+	if e != nil {
+		return nil, e
+	}
+	if substitute.IngredientID != ingredientID {
+		return nil, domain.ModelNotFoundError
+	}
+	return substitute, nil
+}
+
 func NewService(r domain.IngredientRepository) domain.IngredientService {
 	return &service{ingredientRepository: r}
 }
@@ -80,3 +80,60 @@ func TestService_Delete(t *testing.T) {
 		t.Error("test ingredient is not deleted")
 	}
 }
+
+func TestService_Substitutes(t *testing.T) {
+	butter, margarine := gorm.RandomIngredient(), gorm.RandomIngredient()
+	ingredientService.Save(&butter)
+	ingredientService.Save(&margarine)
+	// check save
+	substitute := domain.IngredientSubstitute{SubstituteID: margarine.ID, Ratio: 1.2}
+	err := ingredientService.SaveSubstitute(butter.ID, &substitute)
+	if err != nil {
+		t.Error(err)
+	}
+	// check list
+	substitutes, err := ingredientService.Substitutes(butter.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(substitutes) != 1 || !substitutes[0].Substitute.Equal(&margarine) {
+		t.Fatal("substitute is not saved")
+	}
+	// check update
+	err = ingredientService.UpdateSubstitute(butter.ID, substitute.ID, &domain.IngredientSubstitute{Note: "salted"})
+	if err != nil {
+		t.Error(err)
+	}
+	substitutes, _ = ingredientService.Substitutes(butter.ID)
+	if substitutes[0].Note != "salted" {
+		t.Error("note is not updated")
+	}
+	// check other ingredient
+	err = ingredientService.DeleteSubstitute(margarine.ID, substitute.ID)
+	if err != domain.ModelNotFoundError {
+		t.Error("substitute is deleted by other ingredient")
+	}
+	// check delete
+	err = ingredientService.DeleteSubstitute(butter.ID, substitute.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	substitutes, _ = ingredientService.Substitutes(butter.ID)
+	if len(substitutes) != 0 {
+		t.Error("substitute is not deleted")
+	}
+}
+
+func TestService_Search(t *testing.T) {
+	ingredient := gorm.RandomIngredient()
+	ingredient.Aliases = []domain.IngredientAlias{{Name: "alias_" + ingredient.Name}}
+	ingredientService.Save(&ingredient)
+	// check alias prefix
+	matches, err := ingredientService.Search("alias_"+ingredient.Name[:20], 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(matches) != 1 || !matches[0].Ingredient.Equal(&ingredient) {
+		t.Error("ingredient is not found by alias")
+	}
+}
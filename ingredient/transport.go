@@ -4,52 +4,105 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/go-kit/kit/endpoint"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"net/http"
+	"strconv"
+	"strings"
 	"what_cook/domain"
 	"what_cook/helper"
 )
 
 var badRequest = errors.New("bad request")
 
-func MakeHandler(is domain.IngredientService, logger kitlog.Logger) http.Handler {
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+)
+
+// MakeHandler makes ingredient routes, authenticate makes middlewares which put the caller into the context
+// and check the token scope, reads are allowed for anonymous callers
+func MakeHandler(is domain.IngredientService, authenticate func(domain.Scope) endpoint.Middleware, logger kitlog.Logger) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
 	}
 	ingredientHandler := kithttp.NewServer(
-		makeIngredientEndpoint(is),
+		authenticate(domain.ReadScope)(makeIngredientEndpoint(is)),
 		decodeIngredientRequest,
 		encodeResponse,
 		opts...,
 	)
 	createIngredientHandler := kithttp.NewServer(
-		makeCreateIngredientEndpoint(is),
+		authenticate(domain.WriteScope)(makeCreateIngredientEndpoint(is)),
 		decodeCreateIngredientRequest,
 		encodeResponse,
 		opts...,
 	)
 	updateIngredientHandler := kithttp.NewServer(
-		makeUpdateIngredientEndpoint(is),
+		authenticate(domain.WriteScope)(makeUpdateIngredientEndpoint(is)),
 		decodeUpdateIngredientRequest,
 		encodeResponse,
 		opts...,
 	)
 	deleteIngredientHandler := kithttp.NewServer(
-		makeDeleteIngredientEndpoint(is),
+		authenticate(domain.WriteScope)(makeDeleteIngredientEndpoint(is)),
 		decodeDeleteIngredientRequest,
 		encodeResponse,
 		opts...,
 	)
+	substitutesHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeSubstitutesEndpoint(is)),
+		decodeSubstitutesRequest,
+		encodeResponse,
+		opts...,
+	)
+	createSubstituteHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCreateSubstituteEndpoint(is)),
+		decodeCreateSubstituteRequest,
+		encodeResponse,
+		opts...,
+	)
+	updateSubstituteHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeUpdateSubstituteEndpoint(is)),
+		decodeUpdateSubstituteRequest,
+		encodeResponse,
+		opts...,
+	)
+	deleteSubstituteHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeleteSubstituteEndpoint(is)),
+		decodeDeleteSubstituteRequest,
+		encodeResponse,
+		opts...,
+	)
+	searchIngredientsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeSearchIngredientsEndpoint(is)),
+		decodeSearchIngredientsRequest,
+		encodeResponse,
+		opts...,
+	)
+	listIngredientsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeListIngredientsEndpoint(is)),
+		decodeListIngredientsRequest,
+		encodeResponse,
+		opts...,
+	)
 
 	router := mux.NewRouter()
+	router.Handle("/ingredient/search", searchIngredientsHandler).Methods("GET")
 	router.Handle("/ingredient/{id}", ingredientHandler).Methods("GET")
+	router.Handle("/ingredient/", listIngredientsHandler).Methods("GET")
 	router.Handle("/ingredient/", createIngredientHandler).Methods("POST")
 	router.Handle("/ingredient/{id}", updateIngredientHandler).Methods("PUT")
 	router.Handle("/ingredient/{id}", deleteIngredientHandler).Methods("DELETE")
+	router.Handle("/ingredient/{id}/substitutes", substitutesHandler).Methods("GET")
+	router.Handle("/ingredient/{id}/substitutes", createSubstituteHandler).Methods("POST")
+	router.Handle("/ingredient/{id}/substitutes/{substituteId}", updateSubstituteHandler).Methods("PUT")
+	router.Handle("/ingredient/{id}/substitutes/{substituteId}", deleteSubstituteHandler).Methods("DELETE")
 	return router
 }
 
@@ -87,16 +140,119 @@ func decodeDeleteIngredientRequest(_ context.Context, r *http.Request) (interfac
 	return nil, badRequest
 }
 
+func decodeSubstitutesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return substitutesRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeCreateSubstituteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		var body struct {
+			Substitute domain.IngredientSubstitute `json:"substitute"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			return createSubstituteRequest{id, body.Substitute}, nil
+		}
+	}
+	return nil, badRequest
+}
+
+func decodeUpdateSubstituteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	substituteId, err := helper.GetRequestParam(r, "substituteId")
+	if err != nil {
+		return nil, badRequest
+	}
+	var body struct {
+		Substitute domain.IngredientSubstitute `json:"substitute"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return updateSubstituteRequest{id, substituteId, body.Substitute}, nil
+}
+
+func decodeDeleteSubstituteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	substituteId, err := helper.GetRequestParam(r, "substituteId")
+	if err != nil {
+		return nil, badRequest
+	}
+	return deleteSubstituteRequest{id, substituteId}, nil
+}
+
+func decodeSearchIngredientsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	request := searchIngredientsRequest{
+		Query: strings.TrimSpace(r.URL.Query().Get("q")),
+		Limit: defaultSearchLimit,
+	}
+	if request.Query == "" {
+		return nil, badRequest
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxSearchLimit {
+			return nil, badRequest
+		}
+		request.Limit = l
+	}
+	return request, nil
+}
+
+func decodeListIngredientsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var (
+		query domain.IngredientListQuery
+		err   error
+	)
+	query.Offset, query.Limit, query.Sort, query.Desc, err = helper.GetQueryPage(r)
+	if err != nil {
+		return nil, badRequest
+	}
+	query.NameContains = r.URL.Query().Get("name")
+	if query.CreatedAfter, err = helper.GetQueryTime(r, "createdAfter"); err != nil {
+		return nil, badRequest
+	}
+	if query.MinCalories, err = helper.GetQueryFloat(r, "minCalories"); err != nil {
+		return nil, badRequest
+	}
+	if query.MaxCalories, err = helper.GetQueryFloat(r, "maxCalories"); err != nil {
+		return nil, badRequest
+	}
+	return listIngredientsRequest{query}, nil
+}
+
 type errorer interface {
 	error() error
 }
 
+// exporter is a response which can be rendered in a text media type accepted by the caller
+type exporter interface {
+	exported() domain.Exportable
+}
+
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	e, ok := response.(errorer)
 	if ok && e.error() != nil {
 		encodeError(ctx, e.error(), w)
 		return nil
 	}
+	if x, ok := response.(exporter); ok {
+		// the representation depends on the accept header, caches must not mix them
+		w.Header().Set("Vary", "Accept")
+		accept, _ := ctx.Value(kithttp.ContextKeyRequestAccept).(string)
+		if mediaType := helper.NegotiateMediaType(accept, domain.ExportMediaTypes...); mediaType != "" {
+			w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+			return x.exported().Export(w, mediaType)
+		}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
@@ -104,8 +260,12 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	switch err {
-	case badRequest:
+	case badRequest, domain.InvalidPageError, domain.UnknownAllergenError, domain.UnknownDietError:
 		w.WriteHeader(http.StatusBadRequest)
+	case domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
 	case domain.ModelNotFoundError:
 		w.WriteHeader(http.StatusNotFound)
 	default:
@@ -0,0 +1,162 @@
+package pantry
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"gopkg.in/validator.v2"
+	"strconv"
+	"what_cook/domain"
+)
+
+// visiblePantry returns the pantry only if the caller can use it
+func visiblePantry(ctx context.Context, ps domain.PantryService, id uint) (*domain.Pantry, error) {
+	pantry, err := ps.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !pantry.VisibleTo(domain.ViewerID(ctx)) {
+		return nil, domain.ModelNotFoundError
+	}
+	return pantry, nil
+}
+
+// editablePantry returns the pantry only if the caller can update or delete it,
+// shared pantries are changed only by admins
+func editablePantry(ctx context.Context, ps domain.PantryService, id uint) (*domain.Pantry, error) {
+	pantry, err := visiblePantry(ctx, ps, id)
+	if err != nil {
+		return nil, err
+	}
+	user := domain.ViewerFromContext(ctx)
+	if user == nil {
+		return nil, domain.UnauthenticatedError
+	}
+	if pantry.OwnerID == 0 && !user.HasRole(domain.AdminRole) {
+		return nil, domain.ForbiddenError
+	}
+	return pantry, nil
+}
+
+type pantryRequest struct {
+	ID uint
+}
+
+type pantryResponse struct {
+	Pantry *domain.Pantry
+	Err    error `json:"err,omitempty"`
+}
+
+func (p pantryResponse) error() error {
+	return p.Err
+}
+
+func makePantryEndpoint(ps domain.PantryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(pantryRequest)
+		pantry, e := visiblePantry(ctx, ps, req.ID)
+		return pantryResponse{pantry, e}, nil
+	}
+}
+
+type createPantryRequest struct {
+	Pantry domain.Pantry
+}
+
+type createPantryResponse struct {
+	PantryID string
+	Err      error `json:"err,omitempty"`
+}
+
+func (c createPantryResponse) error() error {
+	return c.Err
+}
+
+func makeCreatePantryEndpoint(ps domain.PantryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createPantryRequest)
+		if error := validator.Validate(req); error != nil {
+			return createPantryResponse{"", error}, nil
+		}
+		req.Pantry.OwnerID = domain.ViewerID(ctx)
+		saveError := ps.Save(&req.Pantry)
+		return createPantryResponse{strconv.Itoa(int(req.Pantry.ID)), saveError}, nil
+	}
+}
+
+type updatePantryRequest struct {
+	ID     uint
+	Pantry domain.Pantry
+}
+
+type updatePantryResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (u updatePantryResponse) error() error {
+	return u.Err
+}
+
+func makeUpdatePantryEndpoint(ps domain.PantryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(updatePantryRequest)
+		if error := validator.Validate(req); error != nil {
+			return updatePantryResponse{error}, nil
+		}
+		if _, e := editablePantry(ctx, ps, req.ID); e != nil {
+			return updatePantryResponse{e}, nil
+		}
+		// the owner is not changed
+		req.Pantry.OwnerID = 0
+		e := ps.Update(req.ID, &req.Pantry)
+		return updatePantryResponse{e}, nil
+	}
+}
+
+type deletePantryRequest struct {
+	ID uint
+}
+
+type deletePantryResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deletePantryResponse) error() error {
+	return d.Err
+}
+
+func makeDeletePantryEndpoint(ps domain.PantryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deletePantryRequest)
+		if _, e := editablePantry(ctx, ps, req.ID); e != nil {
+			return deletePantryResponse{e}, nil
+		}
+		deleteError := ps.Delete(req.ID)
+		return deletePantryResponse{deleteError}, nil
+	}
+}
+
+type pantryFoodsRequest struct {
+	ID uint
+}
+
+type pantryFoodsResponse struct {
+	Foods                 []domain.FoodRecommendation
+	UnresolvedIngredients []string
+	Facets                []domain.TagFacet
+	Err                   error `json:"err,omitempty"`
+}
+
+func (p pantryFoodsResponse) error() error {
+	return p.Err
+}
+
+func makePantryFoodsEndpoint(ps domain.PantryService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(pantryFoodsRequest)
+		if _, e := visiblePantry(ctx, ps, req.ID); e != nil {
+			return pantryFoodsResponse{Err: e}, nil
+		}
+		result, e := ps.FindFoods(req.ID)
+		return pantryFoodsResponse{result.Foods, result.UnresolvedIngredients, result.Facets, e}, nil
+	}
+}
@@ -0,0 +1,42 @@
+package pantry
+
+import (
+	"what_cook/domain"
+)
+
+type service struct {
+	repository  domain.PantryRepository
+	foodService domain.FoodService
+}
+
+func (s *service) Get(id uint) (*domain.Pantry, error) {
+	p, e := s.repository.Get(id)
+	if p == nil {
+		return nil, e
+	}
+	return p.(*domain.Pantry), e
+}
+
+func (s *service) Save(pantry *domain.Pantry) error {
+	return s.repository.Save(pantry)
+}
+
+func (s *service) Update(id uint, pantry *domain.Pantry) error {
+	return s.repository.Update(id, pantry)
+}
+
+func (s *service) Delete(id uint) error {
+	return s.repository.Delete(id)
+}
+
+func (s *service) FindFoods(id uint) (domain.FoodsByIngredientsResult, error) {
+	pantry, err := s.Get(id)
+	if err != nil {
+		return domain.FoodsByIngredientsResult{}, err
+	}
+	return s.foodService.FindByIngredients(pantry.FoodsByIngredientsQuery())
+}
+
+func NewService(r domain.PantryRepository, foodService domain.FoodService) domain.PantryService {
+	return &service{repository: r, foodService: foodService}
+}
@@ -0,0 +1,115 @@
+package pantry
+
+import (
+	"testing"
+	"what_cook/domain"
+	"what_cook/food"
+	"what_cook/gorm"
+	"what_cook/helper"
+)
+
+var (
+	pantryService domain.PantryService
+	testPantry    domain.Pantry
+	testFood      domain.Food
+)
+
+func TestMain(m *testing.M) {
+	// setup
+	db := gorm.SqliteDbSession(gorm.DSN_SQLITE_TEST)
+	gorm.ClearData(db)
+	foodService := food.NewFoodService(gorm.NewFoodRepository(db))
+	pantryRepository := gorm.NewPantryRepository(db)
+	pantryService = NewService(pantryRepository, foodService)
+	testPantry = gorm.CreateRandomPantry(db)
+	testFood = domain.Food{
+		Name: "test_food" + helper.RandomName(),
+		IngredientWeights: []domain.IngredientWeight{
+			{IngredientID: testPantry.Items[0].IngredientID, Weight: 0.2},
+		},
+	}
+	db.Create(&testFood)
+	// run tests
+	m.Run()
+}
+
+func TestService_Get(t *testing.T) {
+	// check not found
+	_, err := pantryService.Get(0)
+	if err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	// check found
+	pantry, err := pantryService.Get(testPantry.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if !testPantry.Equal(pantry) {
+		t.Error("test pantry is not equal returned value")
+	}
+	if len(pantry.Items) != 1 || pantry.Items[0].Ingredient.Name != testPantry.Items[0].Ingredient.Name {
+		t.Error("pantry items are not loaded")
+	}
+}
+
+func TestService_Save(t *testing.T) {
+	// check save
+	pantry := gorm.RandomPantry()
+	err := pantryService.Save(&pantry)
+	if err != nil {
+		t.Error(err)
+	}
+	// check availability
+	savedPantry, err := pantryService.Get(pantry.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if !pantry.Equal(savedPantry) {
+		t.Error("pantry is not equal saved pantry")
+	}
+}
+
+func TestService_FindFoods(t *testing.T) {
+	result, err := pantryService.FindFoods(testPantry.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	foodRecommendations := result.Foods
+	if len(foodRecommendations) != 1 || !foodRecommendations[0].Food.Equal(&testFood) {
+		t.Fatal("pantry food is not found")
+	}
+	if len(foodRecommendations[0].Shortages) != 1 {
+		t.Error("pantry weight is not used")
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	testPantry.Name = "test_pantry" + helper.RandomName()
+	testPantry.Items = []domain.PantryItem{
+		{IngredientID: testPantry.Items[0].IngredientID, Weight: 0.5},
+	}
+	err := pantryService.Update(testPantry.ID, &testPantry)
+	if err != nil {
+		t.Error(err)
+	}
+	// check update
+	pantry, err := pantryService.Get(testPantry.ID)
+	if pantry.Name != testPantry.Name {
+		t.Error("name is not updated")
+	}
+	if len(pantry.Items) != 1 || pantry.Items[0].Weight != 0.5 {
+		t.Error("items are not updated")
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	err := pantryService.Delete(testPantry.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	// check delete
+	_, err = pantryService.Get(testPantry.ID)
+	if err != domain.ModelNotFoundError {
+		t.Error("test pantry is not deleted")
+	}
+}
@@ -0,0 +1,139 @@
+package pantry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"what_cook/domain"
+	"what_cook/helper"
+)
+
+var badRequest = errors.New("bad request")
+
+// MakeHandler makes pantry routes, authenticate makes middlewares which put the caller into the context
+// and check the token scope, reads are allowed for anonymous callers
+func MakeHandler(ps domain.PantryService, authenticate func(domain.Scope) endpoint.Middleware, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
+	}
+	pantryHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makePantryEndpoint(ps)),
+		decodePantryRequest,
+		encodeResponse,
+		opts...,
+	)
+	createPantryHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCreatePantryEndpoint(ps)),
+		decodeCreatePantryRequest,
+		encodeResponse,
+		opts...,
+	)
+	updatePantryHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeUpdatePantryEndpoint(ps)),
+		decodeUpdatePantryRequest,
+		encodeResponse,
+		opts...,
+	)
+	deletePantryHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeletePantryEndpoint(ps)),
+		decodeDeletePantryRequest,
+		encodeResponse,
+		opts...,
+	)
+	pantryFoodsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makePantryFoodsEndpoint(ps)),
+		decodePantryFoodsRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/pantry/{id}", pantryHandler).Methods("GET")
+	router.Handle("/pantry/", createPantryHandler).Methods("POST")
+	router.Handle("/pantry/{id}", updatePantryHandler).Methods("PUT")
+	router.Handle("/pantry/{id}", deletePantryHandler).Methods("DELETE")
+	router.Handle("/pantry/{id}/foods", pantryFoodsHandler).Methods("GET")
+	return router
+}
+
+func decodePantryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return pantryRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeCreatePantryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createPantryRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func decodeUpdatePantryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		var body struct {
+			Pantry domain.Pantry `json:"pantry"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			return updatePantryRequest{id, body.Pantry}, nil
+		}
+	}
+	return nil, badRequest
+}
+
+func decodeDeletePantryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return deletePantryRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodePantryFoodsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return pantryFoodsRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(errorer)
+	if ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch err {
+	case badRequest:
+		w.WriteHeader(http.StatusBadRequest)
+	case domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
+	case domain.ModelNotFoundError:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError) // TODO: debug true|false, logging
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
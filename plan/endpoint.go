@@ -0,0 +1,155 @@
+package plan
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"gopkg.in/validator.v2"
+	"strconv"
+	"what_cook/domain"
+)
+
+// visiblePlan returns the plan only if it belongs to the caller
+func visiblePlan(ctx context.Context, ps domain.MealPlanService, id uint) (*domain.MealPlan, error) {
+	if domain.ViewerFromContext(ctx) == nil {
+		return nil, domain.UnauthenticatedError
+	}
+	plan, err := ps.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.VisibleTo(domain.ViewerID(ctx)) {
+		return nil, domain.ModelNotFoundError
+	}
+	return plan, nil
+}
+
+type planRequest struct {
+	ID uint
+}
+
+type planResponse struct {
+	Plan *domain.MealPlan `json:",omitempty"`
+	Err  error            `json:"err,omitempty"`
+}
+
+func (p planResponse) error() error {
+	return p.Err
+}
+
+func makePlanEndpoint(ps domain.MealPlanService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(planRequest)
+		plan, e := visiblePlan(ctx, ps, req.ID)
+		return planResponse{plan, e}, nil
+	}
+}
+
+type listPlansResponse struct {
+	Plans []domain.MealPlan
+	Err   error `json:"err,omitempty"`
+}
+
+func (l listPlansResponse) error() error {
+	return l.Err
+}
+
+func makeListPlansEndpoint(ps domain.MealPlanService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if domain.ViewerFromContext(ctx) == nil {
+			return listPlansResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		plans, e := ps.List(domain.ViewerID(ctx))
+		return listPlansResponse{plans, e}, nil
+	}
+}
+
+type createPlanRequest struct {
+	Plan domain.MealPlan
+}
+
+type createPlanResponse struct {
+	PlanID string
+	Err    error `json:"err,omitempty"`
+}
+
+func (c createPlanResponse) error() error {
+	return c.Err
+}
+
+func makeCreatePlanEndpoint(ps domain.MealPlanService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createPlanRequest)
+		if domain.ViewerFromContext(ctx) == nil {
+			return createPlanResponse{"", domain.UnauthenticatedError}, nil
+		}
+		if error := validator.Validate(req); error != nil {
+			return createPlanResponse{"", error}, nil
+		}
+		req.Plan.OwnerID = domain.ViewerID(ctx)
+		saveError := ps.Save(&req.Plan)
+		return createPlanResponse{strconv.Itoa(int(req.Plan.ID)), saveError}, nil
+	}
+}
+
+type updatePlanRequest struct {
+	ID   uint
+	Plan domain.MealPlan
+}
+
+type updatePlanResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (u updatePlanResponse) error() error {
+	return u.Err
+}
+
+func makeUpdatePlanEndpoint(ps domain.MealPlanService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(updatePlanRequest)
+		if _, e := visiblePlan(ctx, ps, req.ID); e != nil {
+			return updatePlanResponse{e}, nil
+		}
+		e := ps.Update(req.ID, &req.Plan)
+		return updatePlanResponse{e}, nil
+	}
+}
+
+type deletePlanRequest struct {
+	ID uint
+}
+
+type deletePlanResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deletePlanResponse) error() error {
+	return d.Err
+}
+
+func makeDeletePlanEndpoint(ps domain.MealPlanService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deletePlanRequest)
+		if _, e := visiblePlan(ctx, ps, req.ID); e != nil {
+			return deletePlanResponse{e}, nil
+		}
+		e := ps.Delete(req.ID)
+		return deletePlanResponse{e}, nil
+	}
+}
+
+type autofillPlanRequest struct {
+	ID       uint
+	Autofill domain.MealPlanAutofill
+}
+
+func makeAutofillPlanEndpoint(ps domain.MealPlanService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(autofillPlanRequest)
+		if _, e := visiblePlan(ctx, ps, req.ID); e != nil {
+			return planResponse{nil, e}, nil
+		}
+		plan, e := ps.Autofill(req.ID, req.Autofill)
+		return planResponse{plan, e}, nil
+	}
+}
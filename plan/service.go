@@ -0,0 +1,116 @@
+package plan
+
+import (
+	"what_cook/domain"
+)
+
+type service struct {
+	repository  domain.MealPlanRepository
+	foodService domain.FoodService
+}
+
+func (s *service) Get(id uint) (*domain.MealPlan, error) {
+	p, e := s.repository.Get(id)
+	if p == nil {
+		return nil, e
+	}
+	return p.(*domain.MealPlan), e
+}
+
+func (s *service) Save(plan *domain.MealPlan) error {
+	if err := plan.CheckDays(); err != nil {
+		return err
+	}
+	if err := s.checkSlots(plan); err != nil {
+		return err
+	}
+	return s.repository.Save(plan)
+}
+
+// Update keeps the period and the slots which are not set,
+// the slots are checked against the updated period
+func (s *service) Update(id uint, plan *domain.MealPlan) error {
+	current, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	merged := *current
+	if !plan.StartDate.IsZero() {
+		merged.StartDate = plan.StartDate
+	}
+	if plan.Days != 0 {
+		merged.Days = plan.Days
+	}
+	if plan.Slots != nil {
+		merged.Slots = plan.Slots
+	}
+	if err := merged.CheckDays(); err != nil {
+		return err
+	}
+	if err := s.checkSlots(&merged); err != nil {
+		return err
+	}
+	plan.StartDate = merged.StartDate
+	if plan.Slots != nil {
+		plan.Slots = merged.Slots
+	}
+	// the owner is not changed
+	plan.OwnerID = 0
+	return s.repository.Update(id, plan)
+}
+
+func (s *service) Delete(id uint) error {
+	return s.repository.Delete(id)
+}
+
+func (s *service) List(ownerID uint) ([]domain.MealPlan, error) {
+	return s.repository.List(ownerID)
+}
+
+func (s *service) Autofill(id uint, autofill domain.MealPlanAutofill) (*domain.MealPlan, error) {
+	plan, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	query := autofill.FoodsByIngredientsQuery
+	query.ViewerID = plan.OwnerID
+	result, err := s.foodService.FindByIngredients(query)
+	if err != nil {
+		return nil, err
+	}
+	added, err := plan.Autofill(result.Foods, autofill.Meals, autofill.Servings)
+	if err != nil {
+		return nil, err
+	}
+	if len(added) > 0 {
+		if err := s.repository.Update(id, &domain.MealPlan{Slots: plan.Slots}); err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(id)
+}
+
+// checkSlots checks slots of the plan and foods of slots which the owner can see,
+// servings of foods are set to slots without servings
+func (s *service) checkSlots(plan *domain.MealPlan) error {
+	if err := plan.CheckSlots(); err != nil {
+		return err
+	}
+	for i := range plan.Slots {
+		food, err := s.foodService.Get(plan.Slots[i].FoodID)
+		if err != nil {
+			return err
+		}
+		if !food.VisibleTo(plan.OwnerID) {
+			return domain.ModelNotFoundError
+		}
+		if plan.Slots[i].Servings == 0 {
+			plan.Slots[i].Servings = food.Servings
+		}
+	}
+	return nil
+}
+
+func NewService(r domain.MealPlanRepository, foodService domain.FoodService) domain.MealPlanService {
+	return &service{repository: r, foodService: foodService}
+}
@@ -0,0 +1,135 @@
+package plan
+
+import (
+	"testing"
+	"time"
+	"what_cook/domain"
+	"what_cook/food"
+	"what_cook/gorm"
+	"what_cook/helper"
+)
+
+var (
+	planService domain.MealPlanService
+	foodService domain.FoodService
+	testFood    domain.Food
+	monday      = time.Date(2020, 10, 5, 0, 0, 0, 0, time.UTC)
+)
+
+func TestMain(m *testing.M) {
+	// setup
+	db := gorm.SqliteDbSession(gorm.DSN_SQLITE_TEST)
+	gorm.ClearData(db)
+	foodService = food.NewFoodService(gorm.NewFoodRepository(db))
+	planService = NewService(gorm.NewMealPlanRepository(db), foodService)
+	testFood = gorm.CreateRandomFood(db)
+	// run tests
+	m.Run()
+}
+
+func TestService_Save(t *testing.T) {
+	plan := &domain.MealPlan{
+		OwnerID:   1,
+		Name:      "test_plan" + helper.RandomName(),
+		StartDate: monday.Add(10 * time.Hour),
+		Slots:     []domain.MealSlot{{Date: monday.AddDate(0, 0, 1), Meal: domain.Dinner, FoodID: testFood.ID}},
+	}
+	if err := planService.Save(plan); err != nil {
+		t.Fatal(err)
+	}
+	savedPlan, err := planService.Get(plan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !savedPlan.Equal(plan) || savedPlan.Days != domain.DefaultPlanDays || !savedPlan.StartDate.Equal(monday) {
+		t.Error("plan is not saved")
+	}
+	if len(savedPlan.Slots) != 1 || !savedPlan.Slots[0].Food.Equal(&testFood) || savedPlan.Slots[0].Servings != 1 {
+		t.Error("slots are not saved")
+	}
+	// check food of other user
+	private := gorm.RandomFood()
+	private.OwnerID = 2
+	private.Visibility = domain.PrivateFood
+	foodService.Save(&private)
+	plan = &domain.MealPlan{
+		OwnerID:   1,
+		Name:      "test_plan" + helper.RandomName(),
+		StartDate: monday,
+		Slots:     []domain.MealSlot{{Date: monday, Meal: domain.Lunch, FoodID: private.ID}},
+	}
+	if err := planService.Save(plan); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	plan := &domain.MealPlan{
+		OwnerID:   1,
+		Name:      "test_plan" + helper.RandomName(),
+		StartDate: monday,
+		Slots:     []domain.MealSlot{{Date: monday.AddDate(0, 0, 5), Meal: domain.Lunch, FoodID: testFood.ID}},
+	}
+	planService.Save(plan)
+	// the slot is out of the shorter plan
+	if err := planService.Update(plan.ID, &domain.MealPlan{Days: 3}); err != domain.SlotOutOfPlanError {
+		t.Error("err is not equal error ", domain.SlotOutOfPlanError)
+	}
+	update := &domain.MealPlan{
+		Name:  "updated",
+		Days:  3,
+		Slots: []domain.MealSlot{{Date: monday, Meal: domain.Breakfast, FoodID: testFood.ID, Servings: 2}},
+	}
+	if err := planService.Update(plan.ID, update); err != nil {
+		t.Fatal(err)
+	}
+	savedPlan, _ := planService.Get(plan.ID)
+	if savedPlan.Name != "updated" || savedPlan.Days != 3 || len(savedPlan.Slots) != 1 ||
+		savedPlan.Slots[0].Meal != domain.Breakfast || savedPlan.Slots[0].Servings != 2 {
+		t.Error("plan is not updated")
+	}
+	if err := planService.Delete(plan.ID); err != nil {
+		t.Error(err)
+	}
+	if _, err := planService.Get(plan.ID); err != domain.ModelNotFoundError {
+		t.Error("plan is not deleted")
+	}
+}
+
+func TestService_Autofill(t *testing.T) {
+	// two foods of the ingredient, the first is planned
+	ingredient := gorm.RandomIngredient()
+	planned := domain.Food{Name: helper.RandomName(), IngredientWeights: []domain.IngredientWeight{{Ingredient: ingredient}}}
+	foodService.Save(&planned)
+	other := domain.Food{Name: helper.RandomName(), IngredientWeights: []domain.IngredientWeight{{IngredientID: planned.IngredientWeights[0].IngredientID}}}
+	foodService.Save(&other)
+	plan := &domain.MealPlan{
+		OwnerID:   1,
+		Name:      "test_plan" + helper.RandomName(),
+		StartDate: monday,
+		Days:      2,
+		Slots:     []domain.MealSlot{{Date: monday, Meal: domain.Dinner, FoodID: planned.ID}},
+	}
+	planService.Save(plan)
+	filled, err := planService.Autofill(plan.ID, domain.MealPlanAutofill{
+		FoodsByIngredientsQuery: domain.FoodsByIngredientsQuery{
+			Ingredients: []domain.IngredientQuantity{{Name: ingredient.Name}},
+		},
+		Meals:    []domain.Meal{domain.Dinner},
+		Servings: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filled.Slots) != 2 || filled.Slots[1].FoodID != other.ID || filled.Slots[1].Servings != 3 ||
+		!filled.Slots[1].Date.Equal(monday.AddDate(0, 0, 1)) {
+		t.Error("plan is not filled")
+	}
+	plans, err := planService.List(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(plans) == 0 {
+		t.Error("plans are not listed")
+	}
+}
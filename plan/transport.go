@@ -0,0 +1,161 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"what_cook/domain"
+	"what_cook/helper"
+)
+
+var badRequest = errors.New("bad request")
+
+// MakeHandler makes meal plan routes, plans are used only by their owners,
+// authenticate makes middlewares which put the caller into the context and check the token scope
+func MakeHandler(ps domain.MealPlanService, authenticate func(domain.Scope) endpoint.Middleware, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
+	}
+	planHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makePlanEndpoint(ps)),
+		decodePlanRequest,
+		encodeResponse,
+		opts...,
+	)
+	listPlansHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeListPlansEndpoint(ps)),
+		kithttp.NopRequestDecoder,
+		encodeResponse,
+		opts...,
+	)
+	createPlanHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCreatePlanEndpoint(ps)),
+		decodeCreatePlanRequest,
+		encodeResponse,
+		opts...,
+	)
+	updatePlanHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeUpdatePlanEndpoint(ps)),
+		decodeUpdatePlanRequest,
+		encodeResponse,
+		opts...,
+	)
+	deletePlanHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeletePlanEndpoint(ps)),
+		decodeDeletePlanRequest,
+		encodeResponse,
+		opts...,
+	)
+	autofillPlanHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeAutofillPlanEndpoint(ps)),
+		decodeAutofillPlanRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/plan/{id}", planHandler).Methods("GET")
+	router.Handle("/plan/", listPlansHandler).Methods("GET")
+	router.Handle("/plan/", createPlanHandler).Methods("POST")
+	router.Handle("/plan/{id}", updatePlanHandler).Methods("PUT")
+	router.Handle("/plan/{id}", deletePlanHandler).Methods("DELETE")
+	router.Handle("/plan/{id}/autofill", autofillPlanHandler).Methods("POST")
+	return router
+}
+
+func decodePlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return planRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeCreatePlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, badRequest
+	}
+	return request, nil
+}
+
+func decodeUpdatePlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	var body struct {
+		Plan domain.MealPlan `json:"plan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return updatePlanRequest{id, body.Plan}, nil
+}
+
+func decodeDeletePlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return deletePlanRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+// decodeAutofillPlanRequest reads ingredients and filters as byIngredients request with meals and servings,
+// foods are ranked by the scorer of the scorer query parameter
+func decodeAutofillPlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	var autofill domain.MealPlanAutofill
+	if err := json.NewDecoder(r.Body).Decode(&autofill); err != nil {
+		return nil, badRequest
+	}
+	scorer, err := domain.ScorerByName(r.URL.Query().Get("scorer"))
+	if err != nil {
+		return nil, badRequest
+	}
+	autofill.Scorer = scorer
+	return autofillPlanRequest{id, autofill}, nil
+}
+
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(errorer)
+	if ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch err {
+	case badRequest, domain.InvalidMealError, domain.InvalidPlanDaysError, domain.SlotOutOfPlanError,
+		domain.DuplicateSlotError:
+		w.WriteHeader(http.StatusBadRequest)
+	case domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
+	case domain.ModelNotFoundError:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError) // TODO: debug true|false, logging
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,125 @@
+package tag
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"gopkg.in/validator.v2"
+	"strconv"
+	"what_cook/domain"
+)
+
+type tagRequest struct {
+	ID uint
+}
+
+type tagResponse struct {
+	Tag *domain.Tag
+	Err error `json:"err,omitempty"`
+}
+
+func (t tagResponse) error() error {
+	return t.Err
+}
+
+func makeTagEndpoint(ts domain.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(tagRequest)
+		tag, e := ts.Get(req.ID)
+		return tagResponse{tag, e}, nil
+	}
+}
+
+type listTagsRequest struct {
+	Kind domain.TagKind
+}
+
+type listTagsResponse struct {
+	Tags []domain.Tag
+	Err  error `json:"err,omitempty"`
+}
+
+func (l listTagsResponse) error() error {
+	return l.Err
+}
+
+func makeListTagsEndpoint(ts domain.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listTagsRequest)
+		tags, e := ts.List(req.Kind)
+		return listTagsResponse{tags, e}, nil
+	}
+}
+
+type createTagRequest struct {
+	Tag domain.Tag
+}
+
+type createTagResponse struct {
+	TagID string
+	Err   error `json:"err,omitempty"`
+}
+
+func (c createTagResponse) error() error {
+	return c.Err
+}
+
+func makeCreateTagEndpoint(ts domain.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createTagRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return createTagResponse{"", roleError}, nil
+		}
+		if error := validator.Validate(req); error != nil {
+			return createTagResponse{"", error}, nil
+		}
+		saveError := ts.Save(&req.Tag)
+		return createTagResponse{strconv.Itoa(int(req.Tag.ID)), saveError}, nil
+	}
+}
+
+type updateTagRequest struct {
+	ID  uint
+	Tag domain.Tag
+}
+
+type updateTagResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (u updateTagResponse) error() error {
+	return u.Err
+}
+
+func makeUpdateTagEndpoint(ts domain.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(updateTagRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return updateTagResponse{roleError}, nil
+		}
+		e := ts.Update(req.ID, &req.Tag)
+		return updateTagResponse{e}, nil
+	}
+}
+
+type deleteTagRequest struct {
+	ID uint
+}
+
+type deleteTagResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deleteTagResponse) error() error {
+	return d.Err
+}
+
+func makeDeleteTagEndpoint(ts domain.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteTagRequest)
+		if roleError := domain.RequireRole(ctx, domain.EditorRole); roleError != nil {
+			return deleteTagResponse{roleError}, nil
+		}
+		deleteError := ts.Delete(req.ID)
+		return deleteTagResponse{deleteError}, nil
+	}
+}
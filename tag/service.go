@@ -0,0 +1,51 @@
+package tag
+
+import (
+	"what_cook/domain"
+)
+
+type service struct {
+	repository domain.TagRepository
+}
+
+func (s *service) Get(id uint) (*domain.Tag, error) {
+	t, e := s.repository.Get(id)
+	if t == nil {
+		return nil, e
+	}
+	return t.(*domain.Tag), e
+}
+
+func (s *service) Save(tag *domain.Tag) error {
+	if err := tag.CheckKind(); err != nil {
+		return err
+	}
+	return s.repository.Save(tag)
+}
+
+// Update keeps the kind if it is not set
+func (s *service) Update(id uint, tag *domain.Tag) error {
+	if tag.Kind != "" {
+		if err := tag.CheckKind(); err != nil {
+			return err
+		}
+	}
+	return s.repository.Update(id, tag)
+}
+
+func (s *service) Delete(id uint) error {
+	return s.repository.Delete(id)
+}
+
+func (s *service) List(kind domain.TagKind) ([]domain.Tag, error) {
+	if kind != "" {
+		if err := (&domain.Tag{Kind: kind}).CheckKind(); err != nil {
+			return nil, err
+		}
+	}
+	return s.repository.List(kind)
+}
+
+func NewService(r domain.TagRepository) domain.TagService {
+	return &service{repository: r}
+}
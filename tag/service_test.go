@@ -0,0 +1,110 @@
+package tag
+
+import (
+	"testing"
+	"what_cook/domain"
+	"what_cook/gorm"
+)
+
+var (
+	tagService     domain.TagService
+	foodRepository domain.FoodRepository
+	testTag        domain.Tag
+)
+
+func TestMain(m *testing.M) {
+	// setup
+	db := gorm.SqliteDbSession(gorm.DSN_SQLITE_TEST)
+	gorm.ClearData(db)
+	tagService = NewService(gorm.NewTagRepository(db))
+	foodRepository = gorm.NewFoodRepository(db)
+	testTag = gorm.CreateRandomTag(db)
+	// run tests
+	m.Run()
+}
+
+func TestService_Get(t *testing.T) {
+	// check not found
+	_, err := tagService.Get(0)
+	if err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	// check found
+	tag, err := tagService.Get(testTag.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if !testTag.Equal(tag) {
+		t.Error("test tag is not equal returned value")
+	}
+}
+
+func TestService_Save(t *testing.T) {
+	// check free kind by default
+	tag := domain.Tag{Name: "quick"}
+	err := tagService.Save(&tag)
+	if err != nil {
+		t.Error(err)
+	}
+	savedTag, err := tagService.Get(tag.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if savedTag.Kind != domain.FreeTag {
+		t.Error("tag kind is not free")
+	}
+	// check unknown kind
+	err = tagService.Save(&domain.Tag{Name: "sweet", Kind: "taste"})
+	if err != domain.InvalidTagKindError {
+		t.Error("err is not equal error ", domain.InvalidTagKindError)
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	tag := gorm.RandomTag()
+	tagService.Save(&tag)
+	err := tagService.Update(tag.ID, &domain.Tag{Name: "italian"})
+	if err != nil {
+		t.Error(err)
+	}
+	updatedTag, _ := tagService.Get(tag.ID)
+	if updatedTag.Name != "italian" || updatedTag.Kind != domain.CuisineTag {
+		t.Error("tag is not updated")
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	tag := gorm.RandomTag()
+	tagService.Save(&tag)
+	food := gorm.RandomFood()
+	food.Tags = []domain.Tag{{Model: tag.Model}}
+	if err := foodRepository.Save(&food); err != nil {
+		t.Fatal(err)
+	}
+	err := tagService.Delete(tag.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if _, err := tagService.Get(tag.ID); err != domain.ModelNotFoundError {
+		t.Error("tag is not deleted")
+	}
+	savedFood, _ := foodRepository.Get(food.ID)
+	if len(savedFood.(*domain.Food).Tags) != 0 {
+		t.Error("tag is not removed from food")
+	}
+}
+
+func TestService_List(t *testing.T) {
+	meal := domain.Tag{Name: "breakfast", Kind: domain.MealTag}
+	tagService.Save(&meal)
+	tags, err := tagService.List(domain.MealTag)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tags) != 1 || !tags[0].Equal(&meal) {
+		t.Error("tags are not listed by kind")
+	}
+	if _, err := tagService.List("taste"); err != domain.InvalidTagKindError {
+		t.Error("err is not equal error ", domain.InvalidTagKindError)
+	}
+}
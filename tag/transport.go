@@ -0,0 +1,136 @@
+package tag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"what_cook/domain"
+	"what_cook/helper"
+)
+
+var badRequest = errors.New("bad request")
+
+// MakeHandler makes tag routes, authenticate makes middlewares which put the caller into the context
+// and check the token scope, reads are allowed for anonymous callers
+func MakeHandler(ts domain.TagService, authenticate func(domain.Scope) endpoint.Middleware, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
+	}
+	tagHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeTagEndpoint(ts)),
+		decodeTagRequest,
+		encodeResponse,
+		opts...,
+	)
+	listTagsHandler := kithttp.NewServer(
+		authenticate(domain.ReadScope)(makeListTagsEndpoint(ts)),
+		decodeListTagsRequest,
+		encodeResponse,
+		opts...,
+	)
+	createTagHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeCreateTagEndpoint(ts)),
+		decodeCreateTagRequest,
+		encodeResponse,
+		opts...,
+	)
+	updateTagHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeUpdateTagEndpoint(ts)),
+		decodeUpdateTagRequest,
+		encodeResponse,
+		opts...,
+	)
+	deleteTagHandler := kithttp.NewServer(
+		authenticate(domain.WriteScope)(makeDeleteTagEndpoint(ts)),
+		decodeDeleteTagRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/tag/{id}", tagHandler).Methods("GET")
+	router.Handle("/tag/", listTagsHandler).Methods("GET")
+	router.Handle("/tag/", createTagHandler).Methods("POST")
+	router.Handle("/tag/{id}", updateTagHandler).Methods("PUT")
+	router.Handle("/tag/{id}", deleteTagHandler).Methods("DELETE")
+	return router
+}
+
+func decodeTagRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return tagRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeListTagsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return listTagsRequest{domain.TagKind(r.URL.Query().Get("kind"))}, nil
+}
+
+func decodeCreateTagRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, badRequest
+	}
+	return request, nil
+}
+
+func decodeUpdateTagRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		var body struct {
+			Tag domain.Tag `json:"tag"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			return updateTagRequest{id, body.Tag}, nil
+		}
+	}
+	return nil, badRequest
+}
+
+func decodeDeleteTagRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return deleteTagRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(errorer)
+	if ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch err {
+	case badRequest, domain.InvalidTagKindError:
+		w.WriteHeader(http.StatusBadRequest)
+	case domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
+	case domain.ModelNotFoundError:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError) // TODO: debug true|false, logging
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"strconv"
+	"time"
+	"what_cook/domain"
+)
+
+type registerRequest struct {
+	Name     string
+	Password string
+}
+
+type registerResponse struct {
+	UserID string
+	Err    error `json:"err,omitempty"`
+}
+
+func (r registerResponse) error() error {
+	return r.Err
+}
+
+func makeRegisterEndpoint(us domain.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(registerRequest)
+		user, e := us.Register(req.Name, req.Password)
+		if e != nil {
+			return registerResponse{"", e}, nil
+		}
+		return registerResponse{strconv.Itoa(int(user.ID)), nil}, nil
+	}
+}
+
+type loginRequest struct {
+	Name     string
+	Password string
+}
+
+type loginResponse struct {
+	Token     string
+	ExpiresAt *time.Time `json:",omitempty"`
+	Err       error      `json:"err,omitempty"`
+}
+
+func (l loginResponse) error() error {
+	return l.Err
+}
+
+func makeLoginEndpoint(us domain.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(loginRequest)
+		token, secret, e := us.Login(req.Name, req.Password)
+		if e != nil {
+			return loginResponse{Err: e}, nil
+		}
+		return loginResponse{secret, token.ExpiresAt, nil}, nil
+	}
+}
+
+type meResponse struct {
+	User *domain.User
+	Err  error `json:"err,omitempty"`
+}
+
+func (m meResponse) error() error {
+	return m.Err
+}
+
+func makeMeEndpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		user := domain.ViewerFromContext(ctx)
+		if user == nil {
+			return meResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		return meResponse{user, nil}, nil
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name  string
+	Scope domain.Scope
+}
+
+type createAPIKeyResponse struct {
+	APIKey *domain.Token `json:",omitempty"`
+	// Key is the secret of the API key, it is returned only once
+	Key string `json:",omitempty"`
+	Err error  `json:"err,omitempty"`
+}
+
+func (c createAPIKeyResponse) error() error {
+	return c.Err
+}
+
+func makeCreateAPIKeyEndpoint(us domain.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createAPIKeyRequest)
+		token, secret, e := us.CreateAPIKey(domain.ViewerID(ctx), req.Name, req.Scope)
+		if e != nil {
+			return createAPIKeyResponse{Err: e}, nil
+		}
+		return createAPIKeyResponse{token, secret, nil}, nil
+	}
+}
+
+type apiKeysResponse struct {
+	APIKeys []domain.Token
+	Err     error `json:"err,omitempty"`
+}
+
+func (a apiKeysResponse) error() error {
+	return a.Err
+}
+
+func makeAPIKeysEndpoint(us domain.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		if domain.ViewerFromContext(ctx) == nil {
+			return apiKeysResponse{nil, domain.UnauthenticatedError}, nil
+		}
+		tokens, e := us.APIKeys(domain.ViewerID(ctx))
+		return apiKeysResponse{tokens, e}, nil
+	}
+}
+
+type deleteAPIKeyRequest struct {
+	ID uint
+}
+
+type deleteAPIKeyResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (d deleteAPIKeyResponse) error() error {
+	return d.Err
+}
+
+func makeDeleteAPIKeyEndpoint(us domain.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteAPIKeyRequest)
+		e := us.DeleteAPIKey(domain.ViewerID(ctx), req.ID)
+		return deleteAPIKeyResponse{e}, nil
+	}
+}
+
+type setRoleRequest struct {
+	ID   uint
+	Role domain.Role
+}
+
+type setRoleResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (s setRoleResponse) error() error {
+	return s.Err
+}
+
+func makeSetRoleEndpoint(us domain.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setRoleRequest)
+		if roleError := domain.RequireRole(ctx, domain.AdminRole); roleError != nil {
+			return setRoleResponse{roleError}, nil
+		}
+		e := us.SetRole(req.ID, req.Role)
+		return setRoleResponse{e}, nil
+	}
+}
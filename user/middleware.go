@@ -0,0 +1,54 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"strings"
+	"what_cook/domain"
+)
+
+// Authenticator makes authentication middlewares of required scopes
+type Authenticator func(scope domain.Scope) endpoint.Middleware
+
+// NewAuthenticator returns an Authenticator which resolves bearer tokens and API keys by the service
+func NewAuthenticator(us domain.UserService) Authenticator {
+	return func(scope domain.Scope) endpoint.Middleware {
+		return Authenticate(us, scope)
+	}
+}
+
+// Authenticate is an endpoint middleware which puts the user and the scope of a bearer token
+// into the context and checks the token allows the scope, anonymous requests are allowed only for reads,
+// the request context must be populated by kithttp.PopulateRequestContext
+func Authenticate(us domain.UserService, scope domain.Scope) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			secret := bearerToken(ctx)
+			if secret == "" {
+				if scope != domain.ReadScope {
+					return nil, domain.UnauthenticatedError
+				}
+				return next(ctx, request)
+			}
+			token, err := us.Authenticate(secret)
+			if err != nil {
+				return nil, err
+			}
+			if !token.Scope.Allows(scope) {
+				return nil, domain.InsufficientScopeError
+			}
+			ctx = domain.ContextWithViewer(ctx, &token.User)
+			return next(domain.ContextWithScope(ctx, token.Scope), request)
+		}
+	}
+}
+
+func bearerToken(ctx context.Context) string {
+	authorization, _ := ctx.Value(kithttp.ContextKeyRequestAuthorization).(string)
+	const prefix = "bearer "
+	if len(authorization) < len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(prefix):])
+}
@@ -0,0 +1,147 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
+	"what_cook/domain"
+)
+
+type service struct {
+	repository domain.UserRepository
+	// now is the current time, it is replaced in tests
+	now func() time.Time
+}
+
+func (s *service) Get(id uint) (*domain.User, error) {
+	u, e := s.repository.Get(id)
+	if u == nil {
+		return nil, e
+	}
+	return u.(*domain.User), e
+}
+
+func (s *service) Register(name string, password string) (*domain.User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, domain.InvalidUserNameError
+	}
+	if len(password) < domain.MinPasswordLength {
+		return nil, domain.InvalidPasswordError
+	}
+	if _, err := s.repository.FindByName(name); err != domain.ModelNotFoundError {
+		if err == nil {
+			return nil, domain.UserExistsError
+		}
+		return nil, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &domain.User{Name: name, PasswordHash: string(passwordHash), Role: domain.ViewerRole}
+	return user, s.repository.Save(user)
+}
+
+func (s *service) Login(name string, password string) (*domain.Token, string, error) {
+	user, err := s.repository.FindByName(strings.TrimSpace(name))
+	if err == domain.ModelNotFoundError {
+		return nil, "", domain.InvalidCredentialsError
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, "", domain.InvalidCredentialsError
+	}
+	expiresAt := s.now().Add(domain.TokenLifetime)
+	return s.newToken(&domain.Token{
+		UserID:    user.ID,
+		User:      *user,
+		Scope:     domain.WriteScope,
+		ExpiresAt: &expiresAt,
+	})
+}
+
+func (s *service) Authenticate(secret string) (*domain.Token, error) {
+	token, err := s.repository.FindToken(hashSecret(secret), s.now())
+	if err == domain.ModelNotFoundError {
+		return nil, domain.InvalidTokenError
+	}
+	return token, err
+}
+
+func (s *service) CreateAPIKey(userID uint, name string, scope domain.Scope) (*domain.Token, string, error) {
+	if scope != domain.ReadScope && scope != domain.WriteScope {
+		return nil, "", domain.InvalidScopeError
+	}
+	user, err := s.Get(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.newToken(&domain.Token{
+		UserID: user.ID,
+		User:   *user,
+		Name:   strings.TrimSpace(name),
+		Scope:  scope,
+	})
+}
+
+func (s *service) APIKeys(userID uint) ([]domain.Token, error) {
+	return s.repository.APIKeys(userID)
+}
+
+// DeleteAPIKey deletes the API key only if it belongs to the user
+func (s *service) DeleteAPIKey(userID uint, id uint) error {
+	token, err := s.repository.GetToken(id)
+	if err != nil {
+		return err
+	}
+	if token.UserID != userID || !token.IsAPIKey() {
+		return domain.ModelNotFoundError
+	}
+	return s.repository.DeleteToken(id)
+}
+
+func (s *service) SetRole(id uint, role domain.Role) error {
+	known := false
+	for _, r := range domain.Roles {
+		known = known || r == role
+	}
+	if !known {
+		return domain.InvalidRoleError
+	}
+	return s.repository.Update(id, &domain.User{Role: role})
+}
+
+// newToken saves the token with a hash of a new secret and returns the secret
+func (s *service) newToken(token *domain.Token) (*domain.Token, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	token.Hash = hashSecret(secret)
+	return token, secret, s.repository.SaveToken(token)
+}
+
+// newSecret returns a random token
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashSecret is a hash of a random token, a fast hash is enough for random secrets
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewService(r domain.UserRepository) domain.UserService {
+	return &service{repository: r, now: time.Now}
+}
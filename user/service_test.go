@@ -0,0 +1,151 @@
+package user
+
+import (
+	"testing"
+	"time"
+	"what_cook/domain"
+	"what_cook/gorm"
+	"what_cook/helper"
+)
+
+var (
+	userService domain.UserService
+)
+
+func TestMain(m *testing.M) {
+	// setup
+	db := gorm.SqliteDbSession(gorm.DSN_SQLITE_TEST)
+	gorm.ClearData(db)
+	userService = NewService(gorm.NewUserRepository(db))
+	// run tests
+	m.Run()
+}
+
+func TestService_Register(t *testing.T) {
+	name := "test_user" + helper.RandomName()
+	user, err := userService.Register(name, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedUser, err := userService.Get(user.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if !user.Equal(savedUser) || savedUser.PasswordHash == "password" {
+		t.Error("user is not saved")
+	}
+	// check existing name
+	if _, err := userService.Register(name, "password"); err != domain.UserExistsError {
+		t.Error("err is not equal error ", domain.UserExistsError)
+	}
+	// check short password
+	if _, err := userService.Register("test_user"+helper.RandomName(), "pass"); err != domain.InvalidPasswordError {
+		t.Error("err is not equal error ", domain.InvalidPasswordError)
+	}
+	if _, err := userService.Register("   ", "password"); err != domain.InvalidUserNameError {
+		t.Error("err is not equal error ", domain.InvalidUserNameError)
+	}
+}
+
+func TestService_Login(t *testing.T) {
+	name := "test_user" + helper.RandomName()
+	user, _ := userService.Register(name, "password")
+	// check wrong password
+	if _, _, err := userService.Login(name, "wrong password"); err != domain.InvalidCredentialsError {
+		t.Error("err is not equal error ", domain.InvalidCredentialsError)
+	}
+	// check token
+	token, secret, err := userService.Login(name, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Hash == secret {
+		t.Error("token is stored as is")
+	}
+	authenticated, err := userService.Authenticate(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authenticated.User.Equal(user) || authenticated.Scope != domain.WriteScope {
+		t.Error("wrong authenticated user")
+	}
+	if _, err := userService.Authenticate("wrong token"); err != domain.InvalidTokenError {
+		t.Error("err is not equal error ", domain.InvalidTokenError)
+	}
+	// check expired token
+	s := userService.(*service)
+	s.now = func() time.Time {
+		return time.Now().Add(domain.TokenLifetime + time.Hour)
+	}
+	defer func() {
+		s.now = time.Now
+	}()
+	if _, err := userService.Authenticate(secret); err != domain.InvalidTokenError {
+		t.Error("expired token is accepted")
+	}
+}
+
+func TestService_APIKeys(t *testing.T) {
+	user, _ := userService.Register("test_user"+helper.RandomName(), "password")
+	other, _ := userService.Register("test_user"+helper.RandomName(), "password")
+	// check wrong scope
+	if _, _, err := userService.CreateAPIKey(user.ID, "key", "admin"); err != domain.InvalidScopeError {
+		t.Error("err is not equal error ", domain.InvalidScopeError)
+	}
+	key, secret, err := userService.CreateAPIKey(user.ID, "read key", domain.ReadScope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.IsAPIKey() || key.Hash == secret {
+		t.Error("wrong API key")
+	}
+	// API keys do not expire
+	s := userService.(*service)
+	s.now = func() time.Time {
+		return time.Now().Add(10 * domain.TokenLifetime)
+	}
+	authenticated, err := userService.Authenticate(secret)
+	s.now = time.Now
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authenticated.User.Equal(user) || authenticated.Scope != domain.ReadScope {
+		t.Error("wrong authenticated API key")
+	}
+	// login tokens are not listed
+	userService.Login(user.Name, "password")
+	keys, err := userService.APIKeys(user.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 1 || keys[0].ID != key.ID || keys[0].Name != "read key" {
+		t.Error("wrong API keys ", keys)
+	}
+	// check other user key
+	if err := userService.DeleteAPIKey(other.ID, key.ID); err != domain.ModelNotFoundError {
+		t.Error("err is not equal error ", domain.ModelNotFoundError)
+	}
+	if err := userService.DeleteAPIKey(user.ID, key.ID); err != nil {
+		t.Error(err)
+	}
+	if _, err := userService.Authenticate(secret); err != domain.InvalidTokenError {
+		t.Error("deleted API key is accepted")
+	}
+}
+
+func TestService_SetRole(t *testing.T) {
+	user, _ := userService.Register("test_user"+helper.RandomName(), "password")
+	if user.Role != domain.ViewerRole {
+		t.Error("user is not a viewer")
+	}
+	if err := userService.SetRole(user.ID, "owner"); err != domain.InvalidRoleError {
+		t.Error("err is not equal error ", domain.InvalidRoleError)
+	}
+	if err := userService.SetRole(user.ID, domain.EditorRole); err != nil {
+		t.Fatal(err)
+	}
+	savedUser, _ := userService.Get(user.ID)
+	if savedUser.Role != domain.EditorRole {
+		t.Error("role is not saved")
+	}
+}
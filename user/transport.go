@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"what_cook/domain"
+	"what_cook/helper"
+)
+
+var badRequest = errors.New("bad request")
+
+func MakeHandler(us domain.UserService, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
+	}
+	registerHandler := kithttp.NewServer(
+		makeRegisterEndpoint(us),
+		decodeRegisterRequest,
+		encodeResponse,
+		opts...,
+	)
+	loginHandler := kithttp.NewServer(
+		makeLoginEndpoint(us),
+		decodeLoginRequest,
+		encodeResponse,
+		opts...,
+	)
+	meHandler := kithttp.NewServer(
+		Authenticate(us, domain.ReadScope)(makeMeEndpoint()),
+		kithttp.NopRequestDecoder,
+		encodeResponse,
+		opts...,
+	)
+	createAPIKeyHandler := kithttp.NewServer(
+		Authenticate(us, domain.WriteScope)(makeCreateAPIKeyEndpoint(us)),
+		decodeCreateAPIKeyRequest,
+		encodeResponse,
+		opts...,
+	)
+	apiKeysHandler := kithttp.NewServer(
+		Authenticate(us, domain.ReadScope)(makeAPIKeysEndpoint(us)),
+		kithttp.NopRequestDecoder,
+		encodeResponse,
+		opts...,
+	)
+	deleteAPIKeyHandler := kithttp.NewServer(
+		Authenticate(us, domain.WriteScope)(makeDeleteAPIKeyEndpoint(us)),
+		decodeDeleteAPIKeyRequest,
+		encodeResponse,
+		opts...,
+	)
+	setRoleHandler := kithttp.NewServer(
+		Authenticate(us, domain.WriteScope)(makeSetRoleEndpoint(us)),
+		decodeSetRoleRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	router := mux.NewRouter()
+	router.Handle("/user/", registerHandler).Methods("POST")
+	router.Handle("/user/login", loginHandler).Methods("POST")
+	router.Handle("/user/me", meHandler).Methods("GET")
+	router.Handle("/user/keys", createAPIKeyHandler).Methods("POST")
+	router.Handle("/user/keys", apiKeysHandler).Methods("GET")
+	router.Handle("/user/keys/{id}", deleteAPIKeyHandler).Methods("DELETE")
+	router.Handle("/user/{id}/role", setRoleHandler).Methods("PUT")
+	return router
+}
+
+func decodeRegisterRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request registerRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, badRequest
+	}
+	return request, nil
+}
+
+func decodeLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, badRequest
+	}
+	return request, nil
+}
+
+func decodeCreateAPIKeyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		APIKey createAPIKeyRequest `json:"apiKey"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return body.APIKey, nil
+}
+
+func decodeDeleteAPIKeyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if id, err := helper.GetRequestParam(r, "id"); err == nil {
+		return deleteAPIKeyRequest{id}, nil
+	}
+	return nil, badRequest
+}
+
+func decodeSetRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := helper.GetRequestParam(r, "id")
+	if err != nil {
+		return nil, badRequest
+	}
+	var body struct {
+		Role domain.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest
+	}
+	return setRoleRequest{id, body.Role}, nil
+}
+
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(errorer)
+	if ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch err {
+	case badRequest, domain.InvalidPasswordError, domain.InvalidUserNameError, domain.InvalidScopeError, domain.InvalidRoleError:
+		w.WriteHeader(http.StatusBadRequest)
+	case domain.UserExistsError:
+		w.WriteHeader(http.StatusConflict)
+	case domain.InvalidCredentialsError, domain.InvalidTokenError, domain.UnauthenticatedError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case domain.InsufficientScopeError, domain.ForbiddenError:
+		w.WriteHeader(http.StatusForbidden)
+	case domain.ModelNotFoundError:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError) // TODO: debug true|false, logging
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}